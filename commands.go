@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+	"go.appointy.com/admin-deletion-dashboard/migrations"
+)
+
+// commandUsage lists the commands accepted by the binary
+const commandUsage = `usage: admin-deletion-dashboard <command> [flags]
+
+Commands:
+  serve                                   Start the dashboard server (default)
+  lookup <email>                          Show an account and the groups it owns
+  delete --email --groups --reason        Delete an account after confirmation
+  audit list [filters]                    Search the audit log
+  audit export [--format] [--out] [filters]
+                                          Export the audit log with a signed manifest
+  audit verify [--checkpoint-count --checkpoint-hash]
+                                          Verify the audit log hash chain
+  migrate up|down [n]|status              Apply, revert or list database migrations
+  webhook listen [addr]                   Run a local webhook receiver
+
+lookup, delete and audit list/export act as the operator given by --operator
+or OPERATOR_EMAIL, who must pass the dashboard's sign-in policy, and are
+audited like the same actions in the dashboard. Run a command with -h for
+its flags.
+`
+
+// cliUserAgent is recorded in the audit log for actions taken from the CLI
+const cliUserAgent = "admin-deletion-dashboard-cli"
+
+// runCommand runs a command and returns the process exit code
+func runCommand(config Config, args []string) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	switch {
+	case args[0] == "serve" && len(args) == 1:
+		serve(config)
+		return 0
+	case args[0] == "lookup":
+		return runLookup(config, args[1:])
+	case args[0] == "delete":
+		return runDelete(config, args[1:])
+	case len(args) >= 2 && args[0] == "audit" && args[1] == "list":
+		return runAuditList(config, args[2:])
+	case len(args) >= 2 && args[0] == "audit" && args[1] == "export":
+		return runAuditExport(config, args[2:])
+	case len(args) >= 2 && args[0] == "audit" && args[1] == "verify":
+		return runAuditVerify(config, args[2:])
+	case args[0] == "migrate":
+		return runMigrate(config, args[1:])
+	case len(args) >= 2 && len(args) <= 3 && args[0] == "webhook" && args[1] == "listen":
+		addr := "localhost:9090"
+		if len(args) == 3 {
+			addr = args[2]
+		}
+		return runWebhookListen(addr)
+	case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+		fmt.Print(commandUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %v\n\n", args)
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+}
+
+// parseFlags parses flags that may come before or after positional
+// arguments, which the flag package alone stops at, and returns the
+// positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// commandFlags creates a flag set with the --operator and --output flags
+// shared by the operator commands
+func commandFlags(name string) (fs *flag.FlagSet, operator, output *string) {
+	fs = flag.NewFlagSet(name, flag.ContinueOnError)
+	operator = fs.String("operator", os.Getenv("OPERATOR_EMAIL"), "email of the operator running the command (default $OPERATOR_EMAIL)")
+	output = fs.String("output", "table", "output format: table or json")
+	return fs, operator, output
+}
+
+// commandOperator checks the operator identity against the same access
+// policy as dashboard sign-in. The CLI trusts the stated email, so access to
+// the database credentials is what authenticates the operator.
+func commandOperator(config Config, operator string) (string, error) {
+	operator = strings.ToLower(strings.TrimSpace(operator))
+	if operator == "" {
+		return "", errors.New("an operator identity is required: pass --operator or set OPERATOR_EMAIL")
+	}
+
+	policy, err := newAccessPolicy(config)
+	if err != nil {
+		return "", err
+	}
+
+	_, domain, _ := strings.Cut(operator, "@")
+	identity := &auth.Identity{Email: operator, EmailVerified: true, HostedDomain: domain}
+	if err := policy.AuthorizeIdentity(context.Background(), identity); err != nil {
+		return "", fmt.Errorf("operator %s is not allowed: %w", operator, err)
+	}
+	return operator, nil
+}
+
+// commandMetadata identifies the CLI invocation in the audit log, in place
+// of the HTTP request and session recorded for dashboard actions. It panics
+// if the system's random source fails, like uuid.New.
+func commandMetadata() models.RequestMetadata {
+	host, _ := os.Hostname()
+	session := "cli:" + host
+	if u, err := user.Current(); err == nil {
+		session = "cli:" + u.Username + "@" + host
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate request ID: %v", err))
+	}
+
+	return models.RequestMetadata{
+		UserAgent: cliUserAgent,
+		RequestID: "cli-" + hex.EncodeToString(b),
+		SessionID: session,
+	}
+}
+
+// validOutput checks the --output flag
+func validOutput(output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("invalid output %q, expected table or json", output)
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	out, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(out))
+}
+
+// commandFailed prints an error and returns the failure exit code
+func commandFailed(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	return 1
+}
+
+// runMigrate applies, reverts or lists the embedded migrations. down reverts
+// one migration unless a count is given.
+func runMigrate(config Config, args []string) int {
+	steps := 1
+	switch {
+	case len(args) == 1 && (args[0] == "up" || args[0] == "down" || args[0] == "status"):
+	case len(args) == 2 && args[0] == "down":
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "invalid number of migrations to revert: %s\n", args[1])
+			return 2
+		}
+		steps = n
+	default:
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard migrate up|down [n]|status")
+		return 2
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %s\n", m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+	case "down":
+		reverted, err := runner.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %s\n", m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-9s %-25s %s\n", s.State, appliedAt, s.Name)
+		}
+	}
+	return 0
+}
+
+// runWebhookListen runs a local webhook receiver that prints each delivery and
+// checks its signature against WEBHOOK_SECRET. Set WEBHOOK_RECEIVER_FAIL=true
+// to answer 500 and exercise retries.
+func runWebhookListen(addr string) int {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	fail := getEnvBool("WEBHOOK_RECEIVER_FAIL", false)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		verified := "not checked (WEBHOOK_SECRET unset)"
+		if secret != "" {
+			verified = "ok"
+			if err := service.VerifyWebhookSignature(secret, r.Header.Get("X-Webhook-Signature"), body, 5*time.Minute); err != nil {
+				verified = err.Error()
+			}
+		}
+
+		fmt.Printf("%s %s delivery=%s id=%s signature=%s\n%s\n\n",
+			time.Now().Format(time.RFC3339), r.Header.Get("X-Webhook-Event"), r.Header.Get("X-Webhook-Delivery"),
+			r.Header.Get("X-Webhook-ID"), verified, body)
+
+		if fail {
+			http.Error(w, "receiver configured to fail", http.StatusInternalServerError)
+			return
+		}
+		if secret != "" && verified != "ok" {
+			http.Error(w, verified, http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	fmt.Printf("Listening for webhooks on http://%s\n", addr)
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "webhook receiver failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
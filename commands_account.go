@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// runLookup prints an account and the groups it owns. The lookup is audited
+// and announced like a dashboard lookup, including misses.
+func runLookup(config Config, args []string) int {
+	fs, operatorFlag, output := commandFlags("lookup")
+	positional, err := parseFlags(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil || len(positional) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard lookup <email> [--operator email] [--output table|json]")
+		return 2
+	}
+	if err := validOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	operator, err := commandOperator(config, *operatorFlag)
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		return commandFailed("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	account, err := lookupAccount(ctx, service.NewAccountService(db), operator, positional[0])
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	if *output == "json" {
+		printJSON(account)
+		return 0
+	}
+	printAccount(os.Stdout, account, nil)
+	return 0
+}
+
+// lookupAccount looks up an account, recording the lookup before returning
+// any customer data, as the dashboard does
+func lookupAccount(ctx context.Context, accountService *service.AccountService, operator, email string) (*models.AccountLookupResponse, error) {
+	ctx = logging.With(ctx, logging.Actor(operator, auth.PrincipalUser)...)
+	account, lookupErr := accountService.LookupAccount(ctx, email)
+
+	userID := ""
+	if lookupErr == nil {
+		userID = account.UserID
+	}
+
+	err := accountService.RecordAccess(ctx, &models.AccessEvent{
+		Action:       models.AuditActionAccountLookup,
+		Actor:        operator,
+		ActorType:    auth.PrincipalUser,
+		Query:        email,
+		TargetEmail:  email,
+		TargetUserID: userID,
+		Metadata:     commandMetadata(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = accountService.EnqueueEvent(ctx, &models.Event{
+		Type:  models.EventAccountLookup,
+		Actor: operator,
+		Data: models.LookupEventData{
+			Email:  email,
+			UserID: userID,
+			Found:  lookupErr == nil,
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to emit event", slog.String("event_type", models.EventAccountLookup), logging.Err(err))
+	}
+
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
+	return account, nil
+}
+
+// runDelete deletes an account's user profile and selected groups. It shows
+// the same summary as the dashboard's confirmation dialog and requires the
+// account email to be typed back unless --yes is given.
+func runDelete(config Config, args []string) int {
+	fs, operatorFlag, output := commandFlags("delete")
+	email := fs.String("email", "", "email of the account to delete")
+	groupsFlag := fs.String("groups", "", "comma separated group IDs to delete, or \"all\"")
+	reason := fs.String("reason", "", "reason for the deletion, recorded in the audit log")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+
+	positional, err := parseFlags(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil || len(positional) > 0 || *email == "" || *groupsFlag == "" || strings.TrimSpace(*reason) == "" {
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard delete --email email --groups id,id|all --reason text [--yes] [--operator email] [--output table|json]")
+		return 2
+	}
+	if err := validOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	operator, err := commandOperator(config, *operatorFlag)
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		return commandFailed("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	accountService := service.NewAccountService(db)
+
+	lookupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	account, err := lookupAccount(lookupCtx, accountService, operator, *email)
+	cancel()
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	groupIDs, err := selectGroups(account, *groupsFlag)
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	// The summary goes to stderr so --output json stays machine readable
+	fmt.Fprintln(os.Stderr, "This will soft delete the account and all selected hierarchy.")
+	fmt.Fprintln(os.Stderr)
+	printAccount(os.Stderr, account, groupIDs)
+	fmt.Fprintf(os.Stderr, "\nReason:     %s\nDeleted by: %s\n\n", *reason, operator)
+
+	if !*yes {
+		fmt.Fprintf(os.Stderr, "Type the account email (%s) to confirm: ", account.Email)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), account.Email) {
+			return commandFailed("confirmation did not match, nothing was deleted")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	ctx = logging.With(ctx, logging.Actor(operator, auth.PrincipalUser)...)
+
+	result, err := accountService.DeleteAccount(ctx, &models.DeleteAccountRequest{
+		Email:     account.Email,
+		UserID:    account.UserID,
+		GroupIDs:  groupIDs,
+		Reason:    *reason,
+		DeletedBy: operator,
+		ActorType: auth.PrincipalUser,
+		Metadata:  commandMetadata(),
+	})
+	if err != nil {
+		return commandFailed("failed to delete account: %v", err)
+	}
+
+	if *output == "json" {
+		printJSON(result)
+		return 0
+	}
+	fmt.Printf("Deleted %d groups, %d companies, %d locations (audit entry #%d)\n",
+		result.DeletedGroups, result.DeletedCompanies, result.DeletedLocations, result.AuditID)
+	return 0
+}
+
+// selectGroups resolves --groups against the groups the account owns, so
+// only groups shown in the summary can be deleted
+func selectGroups(account *models.AccountLookupResponse, groups string) ([]string, error) {
+	if groups == "all" {
+		ids := make([]string, 0, len(account.Groups))
+		for _, group := range account.Groups {
+			ids = append(ids, group.ID)
+		}
+		return ids, nil
+	}
+
+	owned := make(map[string]bool, len(account.Groups))
+	for _, group := range account.Groups {
+		owned[group.ID] = true
+	}
+
+	var ids []string
+	for _, id := range strings.Split(groups, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !owned[id] {
+			return nil, fmt.Errorf("group %s is not owned by %s", id, account.Email)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no groups selected")
+	}
+	return ids, nil
+}
+
+// printAccount writes an account and its groups as a table. When selected
+// is set, only those groups are listed, as the ones to be deleted.
+func printAccount(w io.Writer, account *models.AccountLookupResponse, selected []string) {
+	fmt.Fprintf(w, "Account:    %s (%s %s)\n", account.Email, account.FirstName, account.LastName)
+	fmt.Fprintf(w, "User ID:    %s\n\n", account.UserID)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP ID\tNAME\tCOMPANIES\tLOCATIONS")
+	companies, locations, groups := 0, 0, 0
+	for _, group := range account.Groups {
+		if selected != nil && !containsString(selected, group.ID) {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", group.ID, group.Name, group.CompanyCount, group.LocationCount)
+		companies += group.CompanyCount
+		locations += group.LocationCount
+		groups++
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d groups\t%d\t%d\n", groups, companies, locations)
+	tw.Flush()
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
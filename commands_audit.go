@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// auditFilterFlags adds the audit log search flags to fs. The returned
+// function collects the flags that were set as the query string the
+// dashboard API takes, so filters parse and are recorded the same way.
+func auditFilterFlags(fs *flag.FlagSet) func() url.Values {
+	params := map[string]*string{
+		"actor":          fs.String("actor", "", "operator or API key that acted"),
+		"target_email":   fs.String("target-email", "", "email of the affected account"),
+		"target_user_id": fs.String("target-user-id", "", "user ID of the affected account"),
+		"group_id":       fs.String("group", "", "affected group ID"),
+		"action":         fs.String("action", "", "ACCOUNT_DELETION, ACCOUNT_LOOKUP or AUDIT_LOG_VIEW"),
+		"reason":         fs.String("reason", "", "substring of the reason"),
+		"from":           fs.String("from", "", "earliest entry, RFC 3339 or YYYY-MM-DD"),
+		"to":             fs.String("to", "", "latest entry, RFC 3339 or YYYY-MM-DD (inclusive)"),
+	}
+
+	return func() url.Values {
+		values := url.Values{}
+		for key, value := range params {
+			if *value != "" {
+				values.Set(key, *value)
+			}
+		}
+		return values
+	}
+}
+
+// runAuditList prints one page of audit log entries, newest first. The
+// search is itself audited, as in the dashboard.
+func runAuditList(config Config, args []string) int {
+	fs, operatorFlag, output := commandFlags("audit list")
+	filterValues := auditFilterFlags(fs)
+	limit := fs.String("limit", "50", "entries per page, at most 100")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+
+	positional, err := parseFlags(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil || len(positional) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard audit list [filters] [--limit n] [--cursor c] [--operator email] [--output table|json]")
+		return 2
+	}
+	if err := validOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	values := filterValues()
+	values.Set("limit", *limit)
+	if *cursor != "" {
+		values.Set("cursor", *cursor)
+	}
+	filter, err := service.ParseAuditLogFilter(values)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	operator, err := commandOperator(config, *operatorFlag)
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		return commandFailed("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	accountService := service.NewAccountService(db)
+	page, err := accountService.GetAuditLogs(ctx, filter)
+	if err != nil {
+		return commandFailed("failed to get audit logs: %v", err)
+	}
+
+	err = accountService.RecordAccess(ctx, &models.AccessEvent{
+		Action:       models.AuditActionAuditLogView,
+		Actor:        operator,
+		ActorType:    auth.PrincipalUser,
+		Query:        values.Encode(),
+		TargetEmail:  filter.TargetEmail,
+		TargetUserID: filter.TargetUserID,
+		Metadata:     commandMetadata(),
+	})
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	if *output == "json" {
+		printJSON(page)
+		return 0
+	}
+	printAuditLogs(os.Stdout, page)
+	return 0
+}
+
+// printAuditLogs writes a page of audit log entries as a table
+func printAuditLogs(w io.Writer, page *models.AuditLogPage) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tACTION\tACTOR\tTARGET\tGROUPS\tDELETED (G/C/L)\tREASON")
+	for _, entry := range page.Logs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d/%d/%d\t%s\n",
+			entry.ID, entry.CreatedAt.Format("2006-01-02 15:04:05"), entry.Action, entry.DeletedByEmail,
+			entry.TargetEmail, strings.Join(entry.GroupIDs, ","),
+			entry.DeletedGroups, entry.DeletedCompanies, entry.DeletedLocations, entry.Reason)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d of %d entries", len(page.Logs), page.Total)
+	if page.NextCursor != "" {
+		fmt.Fprintf(w, "; next page: --cursor %s", page.NextCursor)
+	}
+	fmt.Fprintln(w)
+}
+
+// runAuditExport writes every matching audit log entry to a file or stdout
+// and prints the signed manifest, which is recorded like a dashboard export
+func runAuditExport(config Config, args []string) int {
+	fs, operatorFlag, _ := commandFlags("audit export")
+	filterValues := auditFilterFlags(fs)
+	format := fs.String("format", models.ExportFormatCSV, "csv or jsonl")
+	out := fs.String("out", "", "file to write the export to (default stdout)")
+
+	positional, err := parseFlags(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil || len(positional) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard audit export [--format csv|jsonl] [--out file] [filters] [--operator email]")
+		return 2
+	}
+	if *format != models.ExportFormatCSV && *format != models.ExportFormatJSONL {
+		fmt.Fprintln(os.Stderr, service.ErrInvalidExportFormat)
+		return 2
+	}
+
+	values := filterValues()
+	values.Set("format", *format)
+	filter, err := service.ParseAuditLogFilter(values)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	operator, err := commandOperator(config, *operatorFlag)
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	signer, err := newSigner(config)
+	if err != nil {
+		return commandFailed("failed to configure signing key: %v", err)
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		return commandFailed("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return commandFailed("failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	id, err := service.NewExportID()
+	if err != nil {
+		return commandFailed("%v", err)
+	}
+
+	req := &models.AuditExportRequest{
+		ID:          id,
+		Format:      *format,
+		Filter:      filter,
+		Query:       values.Encode(),
+		RequestedBy: operator,
+	}
+	accountService := service.NewAccountService(db)
+	manifest, err := service.NewExportService(db, signer).ExportAuditLogs(ctx, req, w, func(rowCount int) error {
+		values.Set("export_id", req.ID)
+		values.Set("rows", strconv.Itoa(rowCount))
+		return accountService.RecordAccess(ctx, &models.AccessEvent{
+			Action:       models.AuditActionAuditLogView,
+			Actor:        operator,
+			ActorType:    auth.PrincipalUser,
+			Query:        values.Encode(),
+			TargetEmail:  filter.TargetEmail,
+			TargetUserID: filter.TargetUserID,
+			Metadata:     commandMetadata(),
+		})
+	})
+	if err != nil {
+		return commandFailed("failed to export audit logs: %v", err)
+	}
+
+	// Keep stdout for the export itself when no file was given
+	if *out == "" {
+		fmt.Fprintf(os.Stderr, "export %s: %d rows, sha256 %s\n", manifest.ID, manifest.RowCount, manifest.SHA256)
+		return 0
+	}
+	printJSON(manifest)
+	return 0
+}
+
+// runAuditVerify walks the audit hash chain and prints the report. It exits
+// non-zero when the chain is broken so it can run from cron or CI.
+func runAuditVerify(config Config, args []string) int {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	count := fs.String("checkpoint-count", "", "checked_entries of an earlier report")
+	hash := fs.String("checkpoint-hash", "", "last_hash of an earlier report")
+
+	positional, err := parseFlags(fs, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil || len(positional) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: admin-deletion-dashboard audit verify [--checkpoint-count n --checkpoint-hash h]")
+		return 2
+	}
+	checkpoint, err := service.ParseAuditChainCheckpoint(*count, *hash)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	db, err := initDatabase(config.DatabaseURL)
+	if err != nil {
+		return commandFailed("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := service.NewAccountService(db).VerifyAuditChain(ctx, checkpoint)
+	if err != nil {
+		return commandFailed("failed to verify audit chain: %v", err)
+	}
+
+	printJSON(report)
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
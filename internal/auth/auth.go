@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/metrics"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// APIKeyAuthenticator validates service account API keys
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// Config holds the identity provider and JWT configuration
+type Config struct {
+	Provider  Provider
+	Policy    *AccessPolicy
+	JWTSecret []byte
+
+	// APIKeys, when set, lets service accounts authenticate with an API key
+	APIKeys APIKeyAuthenticator
+
+	limiter *rateLimiter
+}
+
+// Claims represents JWT claims
+type Claims struct {
+	Email   string   `json:"email"`
+	Name    string   `json:"name"`
+	Picture string   `json:"picture"`
+	Groups  []string `json:"groups,omitempty"`
+	Roles   []string `json:"roles"`
+
+	// ProviderRoles are the roles asserted by the identity provider at
+	// sign-in. Roles are recomputed from them and the access policy on every
+	// request, so the roles claim is informational only.
+	ProviderRoles []string `json:"provider_roles"`
+	jwt.RegisteredClaims
+}
+
+// NewAuthConfig creates a new auth configuration
+func NewAuthConfig(provider Provider, policy *AccessPolicy, jwtSecret string) *Config {
+	return &Config{
+		Provider:  provider,
+		Policy:    policy,
+		JWTSecret: []byte(jwtSecret),
+		limiter:   newRateLimiter(),
+	}
+}
+
+// GetLoginURL generates the identity provider login URL
+func (c *Config) GetLoginURL(ctx context.Context, state string) (string, error) {
+	return c.Provider.LoginURL(ctx, state)
+}
+
+// Authenticate completes the login flow and returns the verified identity
+// after checking it against the access policy
+func (c *Config) Authenticate(ctx context.Context, code, state string) (*Identity, error) {
+	identity, err := c.Provider.Authenticate(ctx, code, state)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Policy.AuthorizeIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// GenerateJWT generates a JWT token for the authenticated user
+func (c *Config) GenerateJWT(identity *Identity) (string, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	// An empty list is written rather than omitted, since a token without
+	// the claim is rejected
+	providerRoles := identity.Roles
+	if providerRoles == nil {
+		providerRoles = []string{}
+	}
+
+	claims := Claims{
+		Email:         identity.Email,
+		Name:          identity.Name,
+		Picture:       identity.Picture,
+		Groups:        identity.Groups,
+		Roles:         c.Policy.RolesFor(identity),
+		ProviderRoles: providerRoles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "admin-deletion-dashboard",
+			ID:        sessionID,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(c.JWTSecret)
+}
+
+// ValidateJWT validates and parses a JWT token
+func (c *Config) ValidateJWT(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return c.JWTSecret, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// Tokens issued before provider roles were recorded cannot have
+		// their roles recomputed
+		if claims.ProviderRoles == nil {
+			return nil, ErrMissingRoles
+		}
+
+		// Re-check the access policy so removed users, and admins removed
+		// from AUTH_ADMIN_EMAILS, lose access immediately
+		if err := c.Policy.AuthorizeEmail(claims.Email, claims.Groups); err != nil {
+			return nil, err
+		}
+		claims.Roles = c.Policy.RolesFor(&Identity{Email: claims.Email, Roles: claims.ProviderRoles})
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// AuthMiddleware is a Gin middleware that authenticates the request with a
+// JWT or, for service accounts, an API key (X-API-Key or Bearer header)
+func (c *Config) AuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		credential := ctx.GetHeader("X-API-Key")
+		if credential == "" {
+			// Extract token from Authorization header
+			authHeader := ctx.GetHeader("Authorization")
+			if authHeader == "" {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header", "code": "unauthorized"})
+				ctx.Abort()
+				return
+			}
+
+			// Remove "Bearer " prefix
+			credential = strings.TrimPrefix(authHeader, "Bearer ")
+			if credential == authHeader {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format", "code": "unauthorized"})
+				ctx.Abort()
+				return
+			}
+		}
+
+		var principal *Principal
+		if c.APIKeys != nil && isAPIKey(credential) {
+			apiKey, err := c.APIKeys.AuthenticateAPIKey(ctx.Request.Context(), credential)
+			if err != nil {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key", "code": "invalid_api_key"})
+				ctx.Abort()
+				return
+			}
+
+			if !c.limiter.Allow(PrincipalAPIKey+":"+strconv.FormatInt(apiKey.ID, 10), apiKey.RateLimitPerMinute) {
+				rejectRateLimited(ctx)
+				return
+			}
+
+			principal = &Principal{
+				Type:   PrincipalAPIKey,
+				ID:     strconv.FormatInt(apiKey.ID, 10),
+				Actor:  "apikey:" + apiKey.Name,
+				Name:   apiKey.Name,
+				Scopes: apiKey.Scopes,
+
+				SessionID: PrincipalAPIKey + ":" + strconv.FormatInt(apiKey.ID, 10),
+			}
+		} else {
+			// Validate token
+			claims, err := c.ValidateJWT(credential)
+			if err != nil {
+				metrics.JWTValidationFailures.WithLabelValues(jwtFailureReason(err)).Inc()
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "code": "invalid_token"})
+				ctx.Abort()
+				return
+			}
+
+			principal = &Principal{
+				Type:   PrincipalUser,
+				ID:     claims.Email,
+				Actor:  claims.Email,
+				Name:   claims.Name,
+				Roles:  claims.Roles,
+				Scopes: ScopesForRoles(claims.Roles),
+
+				SessionID: claims.ID,
+			}
+		}
+
+		// Set user info in context
+		ctx.Set("principal", principal)
+		ctx.Set("user_email", principal.Actor)
+		ctx.Set("user_name", principal.Name)
+		logging.AddFields(ctx, logging.Actor(principal.Actor, principal.Type)...)
+		ctx.Next()
+	}
+}
+
+// RateLimit limits each client IP to perMinute requests to the routes it
+// guards, for public endpoints that have no principal to limit. name keeps
+// the count apart from other rate limited routes.
+func (c *Config) RateLimit(name string, perMinute int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !c.limiter.Allow("ip:"+name+":"+ctx.ClientIP(), perMinute) {
+			rejectRateLimited(ctx)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// rejectRateLimited aborts the request with a 429
+func rejectRateLimited(ctx *gin.Context) {
+	ctx.Header("Retry-After", "60")
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded", "code": "rate_limited"})
+	ctx.Abort()
+}
+
+// jwtFailureReason classifies a token validation error for metrics
+func jwtFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "not_valid_yet"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "invalid_signature"
+	case errors.Is(err, ErrMissingRoles):
+		return "missing_roles"
+	case errors.Is(err, ErrEmailNotVerified), errors.Is(err, ErrDomainNotAllowed),
+		errors.Is(err, ErrEmailNotAllowed), errors.Is(err, ErrGroupNotAllowed):
+		return "access_revoked"
+	default:
+		return "invalid"
+	}
+}
+
+// generateSessionID returns a random identifier for a login session
+func generateSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isAPIKey reports whether the credential looks like an API key rather than a JWT
+func isAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, "adk_")
+}
+
+// GetUserEmailFromContext retrieves the authenticated user's email from context
+func GetUserEmailFromContext(ctx *gin.Context) (string, error) {
+	email, exists := ctx.Get("user_email")
+	if !exists {
+		return "", errors.New("user email not found in context")
+	}
+	emailStr, ok := email.(string)
+	if !ok {
+		return "", errors.New("invalid user email format")
+	}
+	return emailStr, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestValidateJWTRecomputesRoles(t *testing.T) {
+	policy := &AccessPolicy{AllowedDomains: []string{"example.com"}, AdminEmails: []string{"admin@example.com"}}
+	config := NewAuthConfig(nil, policy, "secret")
+
+	token, err := config.GenerateJWT(&Identity{Email: "admin@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := config.ValidateJWT(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{RoleOperator, RoleAdmin}; !reflect.DeepEqual(claims.Roles, want) {
+		t.Fatalf("roles = %v, want %v", claims.Roles, want)
+	}
+
+	// Removing the admin takes effect on the next request
+	policy.AdminEmails = nil
+	claims, err = config.ValidateJWT(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{RoleOperator}; !reflect.DeepEqual(claims.Roles, want) {
+		t.Errorf("roles after removal = %v, want %v", claims.Roles, want)
+	}
+
+	// So does removing the user
+	policy.DeniedEmails = []string{"admin@example.com"}
+	if _, err := config.ValidateJWT(token); !errors.Is(err, ErrEmailNotAllowed) {
+		t.Errorf("err = %v, want %v", err, ErrEmailNotAllowed)
+	}
+}
+
+func TestValidateJWT(t *testing.T) {
+	policy := &AccessPolicy{AllowedDomains: []string{"example.com"}}
+	config := NewAuthConfig(nil, policy, "secret")
+
+	sign := func(claims Claims, key interface{}, method jwt.SigningMethod) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := func() Claims {
+		return Claims{
+			Email:         "a@example.com",
+			Roles:         []string{RoleOperator, RoleAdmin},
+			ProviderRoles: []string{},
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+	}
+
+	expired := valid()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	noRoles := valid()
+	noRoles.ProviderRoles = nil
+	otherDomain := valid()
+	otherDomain.Email = "a@example.org"
+
+	tests := []struct {
+		name       string
+		token      string
+		wantReason string
+		wantRoles  []string
+	}{
+		{name: "valid, roles claim ignored", token: sign(valid(), []byte("secret"), jwt.SigningMethodHS256), wantRoles: []string{RoleOperator}},
+		{name: "expired", token: sign(expired, []byte("secret"), jwt.SigningMethodHS256), wantReason: "expired"},
+		{name: "wrong secret", token: sign(valid(), []byte("other"), jwt.SigningMethodHS256), wantReason: "invalid_signature"},
+		{name: "no roles claim", token: sign(noRoles, []byte("secret"), jwt.SigningMethodHS256), wantReason: "missing_roles"},
+		{name: "access revoked", token: sign(otherDomain, []byte("secret"), jwt.SigningMethodHS256), wantReason: "access_revoked"},
+		{name: "unsigned", token: sign(valid(), jwt.UnsafeAllowNoneSignatureType, jwt.SigningMethodNone), wantReason: "invalid_signature"},
+		{name: "malformed", token: "not-a-token", wantReason: "malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := config.ValidateJWT(tt.token)
+			if tt.wantReason != "" {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if got := jwtFailureReason(err); got != tt.wantReason {
+					t.Errorf("reason = %q, want %q (%v)", got, tt.wantReason, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(claims.Roles, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", claims.Roles, tt.wantRoles)
+			}
+		})
+	}
+}
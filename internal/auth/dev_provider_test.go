@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDevProvider(t *testing.T) {
+	for _, environment := range []string{"production", "staging", ""} {
+		if _, err := NewDevProvider(environment, "/login", []byte("key")); err == nil {
+			t.Errorf("dev provider enabled in %q environment", environment)
+		}
+	}
+	if _, err := NewDevProvider("development", "/login", []byte("key")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDevProviderCode(t *testing.T) {
+	provider, err := NewDevProvider("development", "/login", []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewDevProvider("development", "/login", []byte("other-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := provider.IssueCode("a@example.com", "Ann", []string{RoleAdmin}, "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _, _ := strings.Cut(code, ".")
+
+	// reissue signs a modified payload with the given provider's key
+	reissue := func(p *DevProvider, mutate func(*devCode)) string {
+		raw, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var claims devCode
+		if err := json.Unmarshal(raw, &claims); err != nil {
+			t.Fatal(err)
+		}
+		mutate(&claims)
+		raw, err = json.Marshal(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded := base64.RawURLEncoding.EncodeToString(raw)
+		return encoded + "." + p.sign(encoded)
+	}
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"email":"root@example.com","roles":["admin"],"state":"state","exp":9999999999}`))
+
+	tests := []struct {
+		name    string
+		code    string
+		state   string
+		wantErr string
+	}{
+		{name: "valid", code: code, state: "state"},
+		{name: "other state", code: code, state: "other", wantErr: "state mismatch"},
+		{name: "signed with another key", code: reissue(other, func(*devCode) {}), state: "state", wantErr: "invalid"},
+		{name: "payload swapped", code: forged + code[len(payload):], state: "state", wantErr: "invalid"},
+		{name: "no signature", code: payload, state: "state", wantErr: "invalid"},
+		{name: "not base64", code: "!!!." + provider.sign("!!!"), state: "state", wantErr: "invalid"},
+		{
+			name:    "expired",
+			code:    reissue(provider, func(c *devCode) { c.ExpiresAt = time.Now().Add(-time.Second).Unix() }),
+			state:   "state",
+			wantErr: "expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := provider.Authenticate(context.Background(), tt.code, tt.state)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := &Identity{
+				Subject:       "dev:a@example.com",
+				Email:         "a@example.com",
+				EmailVerified: true,
+				Name:          "Ann",
+				HostedDomain:  "example.com",
+				Roles:         []string{RoleAdmin},
+			}
+			if !reflect.DeepEqual(identity, want) {
+				t.Errorf("identity = %+v, want %+v", identity, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2/google"
+)
+
+const directoryGroupScope = "https://www.googleapis.com/auth/admin.directory.group.member.readonly"
+
+// GoogleGroupChecker checks Google Workspace group membership through the
+// Admin SDK Directory API using a service account with domain-wide delegation
+type GoogleGroupChecker struct {
+	client *http.Client
+}
+
+// NewGoogleGroupChecker creates a checker from a service account JSON key,
+// impersonating adminSubject (a Workspace admin) for directory reads
+func NewGoogleGroupChecker(ctx context.Context, serviceAccountJSON []byte, adminSubject string) (*GoogleGroupChecker, error) {
+	jwtConfig, err := google.JWTConfigFromJSON(serviceAccountJSON, directoryGroupScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	jwtConfig.Subject = adminSubject
+
+	return &GoogleGroupChecker{
+		client: jwtConfig.Client(ctx),
+	}, nil
+}
+
+// MemberGroups returns the groups (by email) that the user belongs to
+func (g *GoogleGroupChecker) MemberGroups(ctx context.Context, email string, groups []string) ([]string, error) {
+	member := make([]string, 0, len(groups))
+	for _, group := range groups {
+		ok, err := g.hasMember(ctx, group, email)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			member = append(member, group)
+		}
+	}
+	return member, nil
+}
+
+// hasMember calls groups.hasMember, which also resolves nested groups
+func (g *GoogleGroupChecker) hasMember(ctx context.Context, group, email string) (bool, error) {
+	endpoint := fmt.Sprintf("https://admin.googleapis.com/admin/directory/v1/groups/%s/hasMember/%s",
+		url.PathEscape(group), url.PathEscape(email))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check membership of %s: %w", group, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to check membership of %s: status %d", group, resp.StatusCode)
+	}
+
+	var result struct {
+		IsMember bool `json:"isMember"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode membership response: %w", err)
+	}
+	return result.IsMember, nil
+}
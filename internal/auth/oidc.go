@@ -0,0 +1,539 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// GoogleIssuer is the OIDC issuer for Google accounts
+	GoogleIssuer = "https://accounts.google.com"
+
+	jwksMinRefreshInterval = time.Minute
+
+	// fetchTimeout bounds a shared fetch, which outlives the caller that
+	// started it
+	fetchTimeout = 10 * time.Second
+)
+
+// supportedSigningAlgs are the asymmetric algorithms accepted for ID tokens
+var supportedSigningAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// ClaimMapping names the ID token claims used to build an Identity
+type ClaimMapping struct {
+	Email         string
+	EmailVerified string
+	Name          string
+	Picture       string
+	HostedDomain  string
+	Groups        string
+}
+
+// DefaultClaimMapping returns the standard OIDC claim names (plus Google's hd)
+func DefaultClaimMapping() ClaimMapping {
+	return ClaimMapping{
+		Email:         "email",
+		EmailVerified: "email_verified",
+		Name:          "name",
+		Picture:       "picture",
+		HostedDomain:  "hd",
+		Groups:        "groups",
+	}
+}
+
+// OIDCConfig holds the settings for an OpenID Connect provider
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Claims       ClaimMapping
+	HTTPClient   *http.Client
+}
+
+// discoveryDocument is the subset of the OIDC discovery document we use
+type discoveryDocument struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// OIDCProvider signs users in with any OpenID Connect compliant provider
+// (Google, Okta, Keycloak, ...) using discovery and ID token verification
+type OIDCProvider struct {
+	config   OIDCConfig
+	nonceKey []byte
+	client   *http.Client
+
+	// mu guards the cached documents. It is never held during a fetch;
+	// concurrent callers wait on the fetch in flight instead.
+	mu             sync.Mutex
+	discovery      *discoveryDocument
+	discoveryFetch *fetch
+	oauth2Config   *oauth2.Config
+	keys           map[string]interface{}
+	keysFetchedAt  time.Time
+	keysFetch      *fetch
+}
+
+// fetch is a request to the provider that concurrent callers share
+type fetch struct {
+	done chan struct{}
+	err  error
+}
+
+// wait blocks until the fetch completes or ctx is done
+func (f *fetch) wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewOIDCProvider creates a new OIDC provider. Discovery is performed lazily
+// on first use so the server can start while the issuer is unreachable.
+func NewOIDCProvider(config OIDCConfig, nonceKey []byte) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if config.Claims == (ClaimMapping{}) {
+		config.Claims = DefaultClaimMapping()
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCProvider{
+		config:   config,
+		nonceKey: nonceKey,
+		client:   client,
+		keys:     make(map[string]interface{}),
+	}
+}
+
+// Name returns the issuer URL
+func (p *OIDCProvider) Name() string {
+	return p.config.IssuerURL
+}
+
+// LoginURL generates the authorization URL for the given state
+func (p *OIDCProvider) LoginURL(ctx context.Context, state string) (string, error) {
+	conf, err := p.getOAuth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return conf.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonceForState(p.nonceKey, state))), nil
+}
+
+// CheckConfig checks the client settings and that the issuer's discovery
+// document can be fetched. The document is cached once fetched.
+func (p *OIDCProvider) CheckConfig(ctx context.Context) error {
+	switch {
+	case p.config.IssuerURL == "":
+		return errors.New("oidc issuer URL is not set")
+	case p.config.ClientID == "":
+		return errors.New("oidc client ID is not set")
+	case p.config.RedirectURL == "":
+		return errors.New("oidc redirect URL is not set")
+	}
+	_, err := p.getDiscovery(ctx)
+	return err
+}
+
+// Authenticate exchanges the code and verifies the returned ID token
+func (p *OIDCProvider) Authenticate(ctx context.Context, code, state string) (*Identity, error) {
+	conf, err := p.getOAuth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := conf.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response did not include an id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawIDToken, nonceForState(p.nonceKey, state))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+
+	return p.mapClaims(claims), nil
+}
+
+// verifyIDToken checks the ID token signature, issuer, audience, authorized
+// party, expiry and nonce
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.getKey(ctx, kid)
+		},
+		jwt.WithValidMethods(p.signingAlgs(doc)),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+
+	// A token for several audiences must name us as the party it was
+	// issued to
+	audiences, _ := claims.GetAudience()
+	azp := claimString(claims, "azp")
+	if len(audiences) > 1 && azp == "" {
+		return nil, errors.New("azp claim is required for a token with several audiences")
+	}
+	if azp != "" && azp != p.config.ClientID {
+		return nil, fmt.Errorf("token was issued to %q", azp)
+	}
+
+	return claims, nil
+}
+
+// mapClaims converts verified ID token claims into an Identity
+func (p *OIDCProvider) mapClaims(claims jwt.MapClaims) *Identity {
+	m := p.config.Claims
+	identity := &Identity{
+		Email:         claimString(claims, m.Email),
+		EmailVerified: claimBool(claims, m.EmailVerified),
+		Name:          claimString(claims, m.Name),
+		Picture:       claimString(claims, m.Picture),
+		HostedDomain:  claimString(claims, m.HostedDomain),
+		Groups:        claimStrings(claims, m.Groups),
+	}
+	identity.Subject, _ = claims.GetSubject()
+	return identity
+}
+
+// getDiscovery fetches and caches the provider's discovery document. A failed
+// fetch is not cached, so the next call tries again.
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	for {
+		p.mu.Lock()
+		if p.discovery != nil {
+			p.mu.Unlock()
+			return p.discovery, nil
+		}
+		if f := p.discoveryFetch; f != nil {
+			p.mu.Unlock()
+			if err := f.wait(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		f := &fetch{done: make(chan struct{})}
+		p.discoveryFetch = f
+		p.mu.Unlock()
+
+		doc, err := p.fetchDiscovery(ctx)
+
+		p.mu.Lock()
+		if err == nil {
+			p.discovery = doc
+			p.oauth2Config = &oauth2.Config{
+				ClientID:     p.config.ClientID,
+				ClientSecret: p.config.ClientSecret,
+				RedirectURL:  p.config.RedirectURL,
+				Scopes:       p.config.Scopes,
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  doc.AuthorizationEndpoint,
+					TokenURL: doc.TokenEndpoint,
+				},
+			}
+		}
+		p.discoveryFetch = nil
+		f.err = err
+		close(f.done)
+		p.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchDiscovery fetches and checks the discovery document
+func (p *OIDCProvider) fetchDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	wellKnown := strings.TrimSuffix(p.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var doc discoveryDocument
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.config.IssuerURL, "/") {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: expected %q, got %q", p.config.IssuerURL, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is missing required endpoints")
+	}
+	return &doc, nil
+}
+
+// getOAuth2Config returns the OAuth2 config built from discovery
+func (p *OIDCProvider) getOAuth2Config(ctx context.Context) (*oauth2.Config, error) {
+	if _, err := p.getDiscovery(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.oauth2Config, nil
+}
+
+// signingAlgs returns the algorithms advertised by the provider that we support
+func (p *OIDCProvider) signingAlgs(doc *discoveryDocument) []string {
+	if len(doc.SigningAlgs) == 0 {
+		return []string{"RS256"}
+	}
+	algs := make([]string, 0, len(doc.SigningAlgs))
+	for _, alg := range doc.SigningAlgs {
+		for _, supported := range supportedSigningAlgs {
+			if alg == supported {
+				algs = append(algs, alg)
+			}
+		}
+	}
+	return algs
+}
+
+// getKey returns the verification key for kid, refreshing the JWKS if the
+// key is unknown (providers rotate keys without notice). Refreshes are
+// limited to one per jwksMinRefreshInterval, and callers arriving during a
+// refresh wait for it rather than fetching again.
+func (p *OIDCProvider) getKey(ctx context.Context, kid string) (interface{}, error) {
+	for {
+		p.mu.Lock()
+		if key, ok := p.lookupKey(kid); ok {
+			p.mu.Unlock()
+			return key, nil
+		}
+		if f := p.keysFetch; f != nil {
+			p.mu.Unlock()
+			if err := f.wait(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if time.Since(p.keysFetchedAt) < jwksMinRefreshInterval {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		f := &fetch{done: make(chan struct{})}
+		p.keysFetch = f
+		jwksURI := p.discovery.JWKSURI
+		p.mu.Unlock()
+
+		keys, err := p.fetchKeys(ctx, jwksURI)
+
+		p.mu.Lock()
+		if err == nil {
+			p.keys = keys
+			p.keysFetchedAt = time.Now()
+		}
+		p.keysFetch = nil
+		f.err = err
+		close(f.done)
+		p.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchKeys fetches the provider's signing keys
+func (p *OIDCProvider) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // Skip key types we don't understand
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// lookupKey finds a cached key. A token without kid is accepted only when
+// the provider publishes exactly one key.
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON performs a GET request and decodes the JSON response. The request
+// is detached from ctx's cancellation, as other callers may be waiting on
+// its result.
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jsonWebKey is a single entry of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts the JWK into an *rsa.PublicKey or *ecdsa.PublicKey
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// claimString reads a string claim
+func claimString(claims jwt.MapClaims, name string) string {
+	if name == "" {
+		return ""
+	}
+	s, _ := claims[name].(string)
+	return s
+}
+
+// claimBool reads a boolean claim, tolerating providers that send "true"
+func claimBool(claims jwt.MapClaims, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// claimStrings reads a list claim, accepting a single string or an array
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	if name == "" {
+		return nil
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
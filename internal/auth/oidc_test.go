@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIssuer serves a discovery document and a JWKS with one RSA key
+type testIssuer struct {
+	*httptest.Server
+	key        *rsa.PrivateKey
+	jwksHits   atomic.Int32
+	jwksGate   chan struct{} // When set, JWKS requests wait for it to close
+	jwksStatus int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := &testIssuer{key: key, jwksStatus: http.StatusOK}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                issuer.URL,
+			AuthorizationEndpoint: issuer.URL + "/authorize",
+			TokenEndpoint:         issuer.URL + "/token",
+			JWKSURI:               issuer.URL + "/jwks",
+			SigningAlgs:           []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksHits.Add(1)
+		if issuer.jwksGate != nil {
+			<-issuer.jwksGate
+		}
+		if issuer.jwksStatus != http.StatusOK {
+			w.WriteHeader(issuer.jwksStatus)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []jsonWebKey{{
+				Kty: "RSA",
+				Kid: "k1",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (i *testIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		IssuerURL:   i.URL,
+		ClientID:    "client",
+		RedirectURL: "http://localhost/callback",
+	}, []byte("nonce-key"))
+}
+
+func (i *testIssuer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	claims := func(mutate func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":   issuer.URL,
+			"sub":   "123",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "n",
+			"email": "a@example.com",
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		claims  jwt.MapClaims
+		wantErr string
+	}{
+		{name: "valid", claims: claims(nil)},
+		{
+			name:    "other audience",
+			claims:  claims(func(c jwt.MapClaims) { c["aud"] = "other" }),
+			wantErr: "audience",
+		},
+		{
+			name:    "several audiences without azp",
+			claims:  claims(func(c jwt.MapClaims) { c["aud"] = []string{"client", "other"} }),
+			wantErr: "azp claim is required",
+		},
+		{
+			name: "several audiences issued to us",
+			claims: claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{"client", "other"}
+				c["azp"] = "client"
+			}),
+		},
+		{
+			name: "several audiences issued to another party",
+			claims: claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{"client", "other"}
+				c["azp"] = "other"
+			}),
+			wantErr: `issued to "other"`,
+		},
+		{
+			name:    "azp of another party",
+			claims:  claims(func(c jwt.MapClaims) { c["azp"] = "other" }),
+			wantErr: `issued to "other"`,
+		},
+		{
+			name:    "other issuer",
+			claims:  claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }),
+			wantErr: "issuer",
+		},
+		{
+			name:    "expired",
+			claims:  claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }),
+			wantErr: "expired",
+		},
+		{
+			name:    "nonce mismatch",
+			claims:  claims(func(c jwt.MapClaims) { c["nonce"] = "other" }),
+			wantErr: "nonce mismatch",
+		},
+		{
+			name:    "unknown key",
+			kid:     "k2",
+			claims:  claims(nil),
+			wantErr: `unknown signing key "k2"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid := tt.kid
+			if kid == "" {
+				kid = "k1"
+			}
+			_, err := issuer.provider().verifyIDToken(context.Background(), issuer.sign(t, kid, tt.claims), "n")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetKeySharesFetch(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.jwksGate = make(chan struct{})
+	provider := issuer.provider()
+	if _, err := provider.getDiscovery(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := provider.getKey(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+
+	// The lock is free while the fetch is blocked, so cached reads proceed
+	for issuer.jwksHits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := provider.getOAuth2Config(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	close(issuer.jwksGate)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if hits := issuer.jwksHits.Load(); hits != 1 {
+		t.Errorf("jwks fetched %d times, want 1", hits)
+	}
+
+	// Unknown keys do not trigger another fetch within the refresh interval
+	if _, err := provider.getKey(context.Background(), "k2"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	if hits := issuer.jwksHits.Load(); hits != 1 {
+		t.Errorf("jwks fetched %d times, want 1", hits)
+	}
+}
+
+func TestGetKeyFetchFailure(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.jwksStatus = http.StatusInternalServerError
+	provider := issuer.provider()
+	if _, err := provider.getDiscovery(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.getKey(context.Background(), "k1"); err == nil || !strings.Contains(err.Error(), "failed to fetch jwks") {
+		t.Fatalf("err = %v, want a fetch error", err)
+	}
+
+	// A failed fetch is retried on the next call
+	issuer.jwksStatus = http.StatusOK
+	if _, err := provider.getKey(context.Background(), "k1"); err != nil {
+		t.Fatal(err)
+	}
+}
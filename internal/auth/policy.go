@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrEmailNotVerified is returned when the provider has not verified the email
+	ErrEmailNotVerified = errors.New("email not verified")
+
+	// ErrDomainNotAllowed is returned when the email or hosted domain is not allowed
+	ErrDomainNotAllowed = errors.New("email domain is not allowed")
+
+	// ErrEmailNotAllowed is returned when the email is denied or missing from the allowlist
+	ErrEmailNotAllowed = errors.New("email is not allowed to access this dashboard")
+
+	// ErrGroupNotAllowed is returned when the user is not in any required group
+	ErrGroupNotAllowed = errors.New("user is not a member of a required group")
+
+	// ErrMissingRoles is returned for a session token without the provider
+	// roles claim, issued by an older version
+	ErrMissingRoles = errors.New("token has no roles claim, sign in again")
+)
+
+// GroupChecker resolves group membership for providers that do not put
+// groups in the ID token (e.g. Google Workspace)
+type GroupChecker interface {
+	// MemberGroups returns the subset of groups the email is a member of
+	MemberGroups(ctx context.Context, email string, groups []string) ([]string, error)
+}
+
+// AccessPolicy decides which identities may use the dashboard
+type AccessPolicy struct {
+	// AllowedDomains are the email / hosted domains allowed to sign in
+	AllowedDomains []string
+
+	// RequireHostedDomain rejects identities without an hd claim
+	// (Google accounts outside a Workspace domain)
+	RequireHostedDomain bool
+
+	// AllowedEmails, when non-empty, restricts access to these emails only
+	AllowedEmails []string
+
+	// DeniedEmails are always rejected, even if otherwise allowed
+	DeniedEmails []string
+
+	// RequiredGroups, when non-empty, requires membership of at least one group
+	RequiredGroups []string
+
+	// AdminEmails are granted the admin role (API key management, diagnostics)
+	AdminEmails []string
+
+	// GroupChecker is consulted when the identity carries no groups claim
+	GroupChecker GroupChecker
+}
+
+// AuthorizeIdentity checks a freshly authenticated identity against the
+// policy. On success the identity's groups are narrowed to the required
+// groups it is a member of so they can be carried in the session token.
+func (p *AccessPolicy) AuthorizeIdentity(ctx context.Context, identity *Identity) error {
+	if !identity.EmailVerified {
+		return ErrEmailNotVerified
+	}
+
+	// The hosted domain is asserted by the provider, unlike the email suffix
+	if identity.HostedDomain != "" || p.RequireHostedDomain {
+		if !p.domainAllowed(identity.HostedDomain) {
+			return ErrDomainNotAllowed
+		}
+	}
+
+	if len(p.RequiredGroups) > 0 && len(identity.Groups) == 0 && p.GroupChecker != nil {
+		groups, err := p.GroupChecker.MemberGroups(ctx, identity.Email, p.RequiredGroups)
+		if err != nil {
+			return fmt.Errorf("failed to check group membership: %w", err)
+		}
+		identity.Groups = groups
+	}
+
+	if err := p.AuthorizeEmail(identity.Email, identity.Groups); err != nil {
+		return err
+	}
+
+	if len(p.RequiredGroups) > 0 {
+		identity.Groups = p.matchingGroups(identity.Groups)
+	}
+	return nil
+}
+
+// AuthorizeEmail checks the email and group claims of an existing session.
+// It runs on every request so policy changes take effect immediately.
+func (p *AccessPolicy) AuthorizeEmail(email string, groups []string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 || !p.domainAllowed(email[at+1:]) {
+		return ErrDomainNotAllowed
+	}
+
+	if containsFold(p.DeniedEmails, email) {
+		return ErrEmailNotAllowed
+	}
+
+	if len(p.AllowedEmails) > 0 && !containsFold(p.AllowedEmails, email) {
+		return ErrEmailNotAllowed
+	}
+
+	if len(p.RequiredGroups) > 0 && len(p.matchingGroups(groups)) == 0 {
+		return ErrGroupNotAllowed
+	}
+
+	return nil
+}
+
+// RolesFor returns the roles granted to an authorized identity: every
+// allowed user is an operator, plus any roles asserted by the provider
+func (p *AccessPolicy) RolesFor(identity *Identity) []string {
+	roles := []string{RoleOperator}
+	if containsFold(p.AdminEmails, strings.ToLower(identity.Email)) {
+		roles = append(roles, RoleAdmin)
+	}
+	for _, role := range identity.Roles {
+		if _, known := roleScopes[role]; known && !containsFold(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// domainAllowed reports whether domain is one of the allowed domains
+func (p *AccessPolicy) domainAllowed(domain string) bool {
+	return domain != "" && containsFold(p.AllowedDomains, strings.TrimPrefix(domain, "@"))
+}
+
+// matchingGroups returns the groups that are also required groups
+func (p *AccessPolicy) matchingGroups(groups []string) []string {
+	matched := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if containsFold(p.RequiredGroups, group) {
+			matched = append(matched, group)
+		}
+	}
+	return matched
+}
+
+// containsFold reports whether list contains value, case-insensitively
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(item), "@"), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// stubGroupChecker returns fixed groups or an error
+type stubGroupChecker struct {
+	groups []string
+	err    error
+}
+
+func (g stubGroupChecker) MemberGroups(ctx context.Context, email string, groups []string) ([]string, error) {
+	return g.groups, g.err
+}
+
+func TestAuthorizeIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     AccessPolicy
+		identity   Identity
+		wantErr    error
+		wantGroups []string
+	}{
+		{
+			name:     "allowed domain",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}},
+			identity: Identity{Email: "a@example.com", EmailVerified: true},
+		},
+		{
+			name:     "domain with leading at and mixed case",
+			policy:   AccessPolicy{AllowedDomains: []string{"@Example.com"}},
+			identity: Identity{Email: "A@EXAMPLE.com", EmailVerified: true},
+		},
+		{
+			name:     "unverified email",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}},
+			identity: Identity{Email: "a@example.com"},
+			wantErr:  ErrEmailNotVerified,
+		},
+		{
+			name:     "other domain",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}},
+			identity: Identity{Email: "a@example.org", EmailVerified: true},
+			wantErr:  ErrDomainNotAllowed,
+		},
+		{
+			name:     "suffix of an allowed domain",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}},
+			identity: Identity{Email: "a@evilexample.com", EmailVerified: true},
+			wantErr:  ErrDomainNotAllowed,
+		},
+		{
+			name:     "hosted domain differs from email",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}},
+			identity: Identity{Email: "a@example.com", EmailVerified: true, HostedDomain: "other.com"},
+			wantErr:  ErrDomainNotAllowed,
+		},
+		{
+			name:     "hosted domain required",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}, RequireHostedDomain: true},
+			identity: Identity{Email: "a@example.com", EmailVerified: true},
+			wantErr:  ErrDomainNotAllowed,
+		},
+		{
+			name:     "denied email",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}, DeniedEmails: []string{"a@example.com"}},
+			identity: Identity{Email: "a@example.com", EmailVerified: true},
+			wantErr:  ErrEmailNotAllowed,
+		},
+		{
+			name:     "not on allowlist",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}, AllowedEmails: []string{"b@example.com"}},
+			identity: Identity{Email: "a@example.com", EmailVerified: true},
+			wantErr:  ErrEmailNotAllowed,
+		},
+		{
+			name:       "required group in claim",
+			policy:     AccessPolicy{AllowedDomains: []string{"example.com"}, RequiredGroups: []string{"support"}},
+			identity:   Identity{Email: "a@example.com", EmailVerified: true, Groups: []string{"eng", "support"}},
+			wantGroups: []string{"support"},
+		},
+		{
+			name:     "required group missing",
+			policy:   AccessPolicy{AllowedDomains: []string{"example.com"}, RequiredGroups: []string{"support"}},
+			identity: Identity{Email: "a@example.com", EmailVerified: true, Groups: []string{"eng"}},
+			wantErr:  ErrGroupNotAllowed,
+		},
+		{
+			name: "required group from checker",
+			policy: AccessPolicy{
+				AllowedDomains: []string{"example.com"},
+				RequiredGroups: []string{"support"},
+				GroupChecker:   stubGroupChecker{groups: []string{"support"}},
+			},
+			identity:   Identity{Email: "a@example.com", EmailVerified: true},
+			wantGroups: []string{"support"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity := tt.identity
+			err := tt.policy.AuthorizeIdentity(context.Background(), &identity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantGroups != nil && !reflect.DeepEqual(identity.Groups, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", identity.Groups, tt.wantGroups)
+			}
+		})
+	}
+
+	t.Run("group checker failure", func(t *testing.T) {
+		checkErr := errors.New("directory unavailable")
+		policy := AccessPolicy{
+			AllowedDomains: []string{"example.com"},
+			RequiredGroups: []string{"support"},
+			GroupChecker:   stubGroupChecker{err: checkErr},
+		}
+		err := policy.AuthorizeIdentity(context.Background(), &Identity{Email: "a@example.com", EmailVerified: true})
+		if !errors.Is(err, checkErr) {
+			t.Errorf("err = %v, want %v", err, checkErr)
+		}
+	})
+}
+
+func TestRolesFor(t *testing.T) {
+	policy := &AccessPolicy{AdminEmails: []string{"Admin@example.com"}}
+
+	tests := []struct {
+		name     string
+		identity Identity
+		want     []string
+	}{
+		{name: "operator", identity: Identity{Email: "a@example.com"}, want: []string{RoleOperator}},
+		{name: "admin email", identity: Identity{Email: "admin@example.com"}, want: []string{RoleOperator, RoleAdmin}},
+		{name: "admin asserted by provider", identity: Identity{Email: "a@example.com", Roles: []string{RoleAdmin}}, want: []string{RoleOperator, RoleAdmin}},
+		{name: "duplicate roles", identity: Identity{Email: "admin@example.com", Roles: []string{RoleAdmin, RoleOperator}}, want: []string{RoleOperator, RoleAdmin}},
+		{name: "unknown role dropped", identity: Identity{Email: "a@example.com", Roles: []string{"superuser"}}, want: []string{RoleOperator}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RolesFor(&tt.identity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RolesFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
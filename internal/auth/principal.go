@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal types
+const (
+	PrincipalUser   = "user"
+	PrincipalAPIKey = "api_key"
+)
+
+// Roles assigned to dashboard users
+const (
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Scopes guarding protected routes
+const (
+	ScopeAccountLookup = "account:lookup"
+	ScopeAccountDelete = "account:delete"
+	ScopeAuditRead     = "audit:read"
+	ScopeAdmin         = "admin"
+)
+
+// roleScopes lists the scopes granted by each role
+var roleScopes = map[string][]string{
+	RoleOperator: {ScopeAccountLookup, ScopeAccountDelete, ScopeAuditRead},
+	RoleAdmin:    {ScopeAccountLookup, ScopeAccountDelete, ScopeAuditRead, ScopeAdmin},
+}
+
+// apiKeyScopes are the scopes that may be granted to API keys
+var apiKeyScopes = []string{ScopeAccountLookup, ScopeAccountDelete, ScopeAuditRead}
+
+// Principal is the authenticated caller of a protected route: a signed-in
+// user or a service account API key
+type Principal struct {
+	Type   string   `json:"type"`
+	ID     string   `json:"id"`
+	Actor  string   `json:"actor"` // Recorded as deleted_by and in audit logs
+	Name   string   `json:"name"`
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes"`
+
+	// SessionID identifies the login session (JWT ID) or API key
+	SessionID string `json:"session_id"`
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesForRoles returns the union of scopes granted by roles
+func ScopesForRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	scopes := make([]string, 0)
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// ValidateAPIKeyScopes checks that every scope may be granted to an API key
+func ValidateAPIKeyScopes(scopes []string) error {
+	for _, scope := range scopes {
+		valid := false
+		for _, allowed := range apiKeyScopes {
+			if scope == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("scope %q cannot be granted to an API key", scope)
+		}
+	}
+	return nil
+}
+
+// RequireScope is a Gin middleware that rejects principals without scope.
+// It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := GetPrincipalFromContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "code": "unauthorized"})
+			ctx.Abort()
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "missing required scope: " + scope, "code": "forbidden"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// GetPrincipalFromContext retrieves the authenticated principal from context
+func GetPrincipalFromContext(ctx *gin.Context) (*Principal, error) {
+	value, exists := ctx.Get("principal")
+	if !exists {
+		return nil, errors.New("principal not found in context")
+	}
+	principal, ok := value.(*Principal)
+	if !ok {
+		return nil, errors.New("invalid principal format")
+	}
+	return principal, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a fixed-window per-minute limiter keyed by principal or
+// client IP
+type rateLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+// rateWindow counts requests within the current minute
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// newRateLimiter creates an empty rate limiter
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow records a request for key and reports whether it is within limit.
+// A limit of zero or less means unlimited.
+func (l *rateLimiter) Allow(key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		l.windows[key] = window
+	}
+
+	if window.count >= limit {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// sweep drops expired windows, at most once a minute, so keys that stop
+// sending requests do not accumulate
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, window := range l.windows {
+		if now.Sub(window.start) >= time.Minute {
+			delete(l.windows, key)
+		}
+	}
+}
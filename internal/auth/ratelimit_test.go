@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		requests int
+		want     int // Requests allowed
+	}{
+		{name: "unlimited", limit: 0, requests: 50, want: 50},
+		{name: "negative is unlimited", limit: -1, requests: 5, want: 5},
+		{name: "under the limit", limit: 10, requests: 3, want: 3},
+		{name: "over the limit", limit: 3, requests: 10, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter()
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				if limiter.Allow("key", tt.limit) {
+					allowed++
+				}
+			}
+			if allowed != tt.want {
+				t.Errorf("allowed %d requests, want %d", allowed, tt.want)
+			}
+			if !limiter.Allow("other", tt.limit) {
+				t.Error("limit leaked into another key")
+			}
+		})
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	limiter := newRateLimiter()
+	if !limiter.Allow("key", 1) || limiter.Allow("key", 1) {
+		t.Fatal("expected one request per window")
+	}
+
+	// A new window starts a minute later, and expired windows are dropped
+	limiter.windows["key"].start = time.Now().Add(-time.Minute)
+	limiter.windows["idle"] = &rateWindow{start: time.Now().Add(-2 * time.Minute)}
+	limiter.lastSweep = time.Now().Add(-time.Minute)
+	if !limiter.Allow("key", 1) {
+		t.Error("expected the window to reset")
+	}
+	if _, ok := limiter.windows["idle"]; ok {
+		t.Error("expired window was not swept")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := NewAuthConfig(nil, &AccessPolicy{}, "secret")
+
+	router := gin.New()
+	router.GET("/a", config.RateLimit("a", 2), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/b", config.RateLimit("b", 2), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		path string
+		ip   string
+		want int
+	}{
+		{"/a", "10.0.0.1", http.StatusOK},
+		{"/a", "10.0.0.1", http.StatusOK},
+		{"/a", "10.0.0.1", http.StatusTooManyRequests},
+		{"/a", "10.0.0.2", http.StatusOK},
+		{"/b", "10.0.0.1", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := get(tt.path, tt.ip)
+		if w.Code != tt.want {
+			t.Errorf("GET %s from %s = %d, want %d", tt.path, tt.ip, w.Code, tt.want)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+}
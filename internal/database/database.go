@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Connection states reported by a Manager
+const (
+	StateConnecting   = "connecting"
+	StateConnected    = "connected"
+	StateDisconnected = "disconnected"
+)
+
+// Open opens a connection pool without connecting. Connections are made on
+// first use and remade by database/sql after they are lost. Statements are
+// traced with their text but not their arguments.
+func Open(databaseURL string) (*sql.DB, error) {
+	if databaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL is required")
+	}
+
+	db, err := otelsql.Open("postgres", databaseURL,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			// Only trace statements run within a traced request or service
+			// call, not every poll of the background jobs
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Set connection timeouts and limits
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxIdleConns(2)
+	db.SetMaxOpenConns(5)
+
+	return db, nil
+}
+
+// Status is a snapshot of a Manager's connection state
+type Status struct {
+	State       string     `json:"state"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+}
+
+// SetupFunc runs once after the database is first reached, before the
+// manager reports it as connected
+type SetupFunc func(ctx context.Context, db *sql.DB) error
+
+// Manager watches a connection pool. It pings until the database is
+// reachable, backing off between failed attempts, then keeps checking so
+// an outage is noticed and recovered from without a restart.
+type Manager struct {
+	// MinBackoff and MaxBackoff bound the wait between failed attempts
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// CheckInterval is the wait between checks while connected
+	CheckInterval time.Duration
+	// PingTimeout bounds each check
+	PingTimeout time.Duration
+
+	db    *sql.DB
+	setup []SetupFunc
+
+	mu          sync.RWMutex
+	state       string
+	attempts    int
+	lastErr     error
+	connectedAt time.Time
+	checkedAt   time.Time
+	setupDone   bool
+	connected   chan struct{}
+}
+
+// NewManager creates a manager for db. Run must be called to connect.
+func NewManager(db *sql.DB) *Manager {
+	return &Manager{
+		MinBackoff:    time.Second,
+		MaxBackoff:    time.Minute,
+		CheckInterval: 15 * time.Second,
+		PingTimeout:   5 * time.Second,
+		db:            db,
+		state:         StateConnecting,
+		connected:     make(chan struct{}),
+	}
+}
+
+// OnConnect adds a setup step, such as applying migrations or starting
+// background jobs. Steps run in order on the first successful connection; if
+// one fails the connection is retried as if the ping had failed. It must be
+// called before Run.
+func (m *Manager) OnConnect(fn SetupFunc) {
+	m.setup = append(m.setup, fn)
+}
+
+// DB returns the connection pool. It is never nil, but queries fail while
+// the database is unreachable.
+func (m *Manager) DB() *sql.DB {
+	return m.db
+}
+
+// Ready reports whether the database was reachable at the last check
+func (m *Manager) Ready() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state == StateConnected
+}
+
+// Connected returns a channel that is closed once the database is first
+// connected and set up
+func (m *Manager) Connected() <-chan struct{} {
+	return m.connected
+}
+
+// Status returns the current connection state
+func (m *Manager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{State: m.state, Attempts: m.attempts}
+	if m.lastErr != nil {
+		status.LastError = m.lastErr.Error()
+	}
+	if !m.connectedAt.IsZero() {
+		connectedAt := m.connectedAt
+		status.ConnectedAt = &connectedAt
+	}
+	if !m.checkedAt.IsZero() {
+		checkedAt := m.checkedAt
+		status.CheckedAt = &checkedAt
+	}
+	return status
+}
+
+// Ping pings the database once and returns how long it took. It does not
+// change the manager's state.
+func (m *Manager) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	err := m.db.PingContext(ctx)
+	return time.Since(start), err
+}
+
+// Run checks the connection until ctx is cancelled
+func (m *Manager) Run(ctx context.Context) {
+	backoff := m.MinBackoff
+	for {
+		wait := m.CheckInterval
+		if err := m.check(ctx); err != nil {
+			wait = backoff
+			backoff *= 2
+			if backoff > m.MaxBackoff {
+				backoff = m.MaxBackoff
+			}
+		} else {
+			backoff = m.MinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// check pings the database, runs the setup steps if they have not yet
+// succeeded, and records the outcome
+func (m *Manager) check(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, m.PingTimeout)
+	err := m.db.PingContext(pingCtx)
+	cancel()
+
+	if err == nil && !m.setupDone {
+		for _, fn := range m.setup {
+			if err = fn(ctx, m.db); err != nil {
+				err = fmt.Errorf("database setup failed: %w", err)
+				break
+			}
+		}
+	}
+
+	m.record(err)
+	return err
+}
+
+// record updates the state after a check and logs transitions
+func (m *Manager) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	m.checkedAt = now
+
+	if err != nil {
+		m.attempts++
+		m.lastErr = err
+		switch m.state {
+		case StateConnected:
+			m.state = StateDisconnected
+			slog.Error("database connection lost", logging.Err(err))
+		default:
+			slog.Warn("database unavailable", slog.Int("attempt", m.attempts), logging.Err(err))
+		}
+		return
+	}
+
+	if m.state != StateConnected {
+		if m.setupDone {
+			slog.Info("database reconnected", slog.Int("failed_checks", m.attempts))
+		} else {
+			slog.Info("database connected")
+			m.setupDone = true
+			close(m.connected)
+		}
+		m.connectedAt = now
+	}
+	m.state = StateConnected
+	m.attempts = 0
+	m.lastErr = nil
+}
@@ -0,0 +1,201 @@
+package diagnostics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version      string    `json:"version"`
+	GoVersion    string    `json:"go_version"`
+	Revision     string    `json:"revision,omitempty"`
+	RevisionTime string    `json:"revision_time,omitempty"`
+	Modified     bool      `json:"modified,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// ReadBuildInfo returns the build information embedded by the Go toolchain.
+// version is set at build time with -ldflags "-X main.version=...".
+func ReadBuildInfo(version string) BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		StartedAt: time.Now().UTC(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.RevisionTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// PoolStats are the connection pool statistics of a database
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMS     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// NewPoolStats reads the pool statistics of db
+func NewPoolStats(db *sql.DB) PoolStats {
+	stats := db.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMS:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// EgressResult is the outcome of an outbound request
+type EgressResult struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	OutboundIP string `json:"outbound_ip,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CheckEgress makes a GET request to rawURL to check outbound connectivity.
+// If the response is JSON with an "ip" field, as from an IP echo service, it
+// is reported as the outbound IP, which is useful for database allowlists.
+func CheckEgress(ctx context.Context, client *http.Client, rawURL string) EgressResult {
+	result := EgressResult{URL: MaskURL(rawURL)}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid egress URL: %v", err)
+		return result
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = MaskURL(err.Error())
+		return result
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	var body struct {
+		IP string `json:"ip"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body) == nil {
+		result.OutboundIP = body.IP
+	}
+	return result
+}
+
+// ErrorEntry is a failed request
+type ErrorEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	RequestID string    `json:"request_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ErrorLog keeps the most recent server errors in memory
+type ErrorLog struct {
+	mu      sync.Mutex
+	entries []ErrorEntry
+	next    int
+	full    bool
+}
+
+// NewErrorLog creates a log keeping the last size errors
+func NewErrorLog(size int) *ErrorLog {
+	return &ErrorLog{entries: make([]ErrorEntry, size)}
+}
+
+// Record adds an error, dropping the oldest once the log is full
+func (l *ErrorLog) Record(entry ErrorEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns the recorded errors, newest first
+func (l *ErrorLog) Recent() []ErrorEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+
+	recent := make([]ErrorEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return recent
+}
+
+// Middleware records every response with a 5xx status, with the error the
+// handler attached to the context, if any
+func (l *ErrorLog) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError {
+			return
+		}
+
+		entry := ErrorEntry{
+			Time:      time.Now().UTC(),
+			Method:    c.Request.Method,
+			Path:      c.FullPath(),
+			Status:    status,
+			RequestID: c.GetString("request_id"),
+		}
+		if entry.Path == "" {
+			entry.Path = c.Request.URL.Path
+		}
+		if last := c.Errors.Last(); last != nil {
+			entry.Error = last.Error()
+		}
+		l.Record(entry)
+	}
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
 	"go.appointy.com/admin-deletion-dashboard/internal/models"
 	"go.appointy.com/admin-deletion-dashboard/internal/service"
 )
@@ -26,14 +28,32 @@ func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
 func (h *AccountHandler) HandleLookup(c *gin.Context) {
 	var req models.AccountLookupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalid(c, err.Error())
 		return
 	}
 
 	// Lookup account
-	result, err := h.accountService.LookupAccount(c.Request.Context(), req.Email)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	result, lookupErr := h.accountService.LookupAccount(c.Request.Context(), req.Email)
+
+	// Record the lookup, including misses, before any customer data is returned
+	resultUserID := ""
+	if lookupErr == nil {
+		resultUserID = result.UserID
+		logging.AddFields(c, logging.TargetUserID(resultUserID))
+	}
+	if err := recordAccess(c, h.accountService, models.AuditActionAccountLookup, req.Email, req.Email, resultUserID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.emit(c, models.EventAccountLookup, models.LookupEventData{
+		Email:  req.Email,
+		UserID: resultUserID,
+		Found:  lookupErr == nil,
+	})
+
+	if lookupErr != nil {
+		respondError(c, lookupErr)
 		return
 	}
 
@@ -44,56 +64,131 @@ func (h *AccountHandler) HandleLookup(c *gin.Context) {
 func (h *AccountHandler) HandleDelete(c *gin.Context) {
 	var req models.DeleteAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalid(c, err.Error())
 		return
 	}
 
-	// Get authenticated user's email from context
-	deletedBy, err := auth.GetUserEmailFromContext(c)
+	// Get authenticated user or API key from context
+	principal, err := auth.GetPrincipalFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		respondUnauthorized(c)
 		return
 	}
 
 	// Set deleted_by field
-	req.DeletedBy = deletedBy
+	req.DeletedBy = principal.Actor
+	req.ActorType = principal.Type
+	req.Metadata = requestMetadata(c)
+	logging.AddFields(c, logging.TargetUserID(req.UserID))
 
 	// Perform deletion
 	result, err := h.accountService.DeleteAccount(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
+	logging.AddFields(c, logging.AuditID(result.AuditID))
 
 	c.JSON(http.StatusOK, result)
 }
 
-// HandleGetAuditLogs retrieves audit logs
+// HandleGetAuditLogs searches audit logs with filters and cursor pagination
 func (h *AccountHandler) HandleGetAuditLogs(c *gin.Context) {
-	// Parse pagination parameters
-	limitStr := c.DefaultQuery("limit", "50")
-	offsetStr := c.DefaultQuery("offset", "0")
+	filter, err := service.ParseAuditLogFilter(c.Request.URL.Query())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Get audit logs
+	page, err := h.accountService.GetAuditLogs(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		limit = 50
+	if err := recordAccess(c, h.accountService, models.AuditActionAuditLogView, c.Request.URL.RawQuery, filter.TargetEmail, filter.TargetUserID); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	c.JSON(http.StatusOK, page)
+}
+
+// HandleGetAuditLog retrieves a single audit log entry with its affected entities
+func (h *AccountHandler) HandleGetAuditLog(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid audit log id")
+		return
 	}
 
-	// Get audit logs
-	logs, err := h.accountService.GetAuditLogs(c.Request.Context(), limit, offset)
+	log, err := h.accountService.GetAuditLog(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"logs":   logs,
-		"limit":  limit,
-		"offset": offset,
-	})
+	if err := recordAccess(c, h.accountService, models.AuditActionAuditLogView, "id="+log.ID, log.TargetEmail, log.TargetUserID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, log)
+}
+
+// HandleAccessReport lists who looked up or viewed audit data about a
+// customer, by email and/or user_id
+func (h *AccountHandler) HandleAccessReport(c *gin.Context) {
+	email := c.Query("email")
+	userID := c.Query("user_id")
+	if email == "" && userID == "" {
+		respondInvalid(c, "email or user_id is required")
+		return
+	}
+
+	report, err := h.accountService.GetAccessReport(c.Request.Context(), email, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Reading the report is itself a view of this customer's audit data
+	if err := recordAccess(c, h.accountService, models.AuditActionAuditLogView, c.Request.URL.RawQuery, email, userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// HandleVerifyAuditChain verifies the audit log hash chain, optionally
+// against a checkpoint given as checkpoint_count and checkpoint_hash
+func (h *AccountHandler) HandleVerifyAuditChain(c *gin.Context) {
+	checkpoint, err := service.ParseAuditChainCheckpoint(c.Query("checkpoint_count"), c.Query("checkpoint_hash"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	report, err := h.accountService.VerifyAuditChain(c.Request.Context(), checkpoint)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// emit writes an event to the outbox. Failures are logged rather than failing
+// the request, since the action itself has already happened.
+func (h *AccountHandler) emit(c *gin.Context, eventType string, data interface{}) {
+	event := &models.Event{
+		Type:  eventType,
+		Actor: c.GetString("user_email"),
+		Data:  data,
+	}
+	if err := h.accountService.EnqueueEvent(c.Request.Context(), event); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to emit event", slog.String("event_type", eventType), logging.Err(err))
+	}
 }
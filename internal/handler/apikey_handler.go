@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// APIKeyHandler handles service account API key management endpoints
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// HandleCreate creates a new API key and returns its secret once
+func (h *APIKeyHandler) HandleCreate(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, err.Error())
+		return
+	}
+
+	if err := auth.ValidateAPIKeyScopes(req.Scopes); err != nil {
+		respondInvalid(c, err.Error())
+		return
+	}
+
+	// Get authenticated admin's email from context
+	createdBy, err := auth.GetUserEmailFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+	req.CreatedBy = createdBy
+
+	result, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// HandleList lists all API keys without their secrets
+func (h *APIKeyHandler) HandleList(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+// HandleRevoke revokes an API key
+func (h *APIKeyHandler) HandleRevoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid api key id")
+		return
+	}
+
+	revokedBy, err := auth.GetUserEmailFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id, revokedBy); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "api key revoked",
+	})
+}
@@ -3,11 +3,15 @@ package handler
 import (
 	"crypto/rand"
 	"encoding/base64"
-	//"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/metrics"
 )
 
 // AuthHandler handles authentication endpoints
@@ -24,34 +28,39 @@ func NewAuthHandler(authConfig *auth.Config) *AuthHandler {
 	}
 }
 
-// HandleLogin initiates the OAuth2 login flow
+// HandleLogin initiates the identity provider login flow
 func (h *AuthHandler) HandleLogin(c *gin.Context) {
 	// Generate random state
 	state, err := generateRandomState()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state"})
+		writeError(c, http.StatusInternalServerError, "internal_error", "failed to generate state")
 		return
 	}
 
 	// Store state (in production, store in Redis with expiration)
 	h.sessions[state] = "pending"
 
-	// Get OAuth2 URL
-	url := h.authConfig.GetLoginURL(state)
+	// Get provider login URL
+	loginURL, err := h.authConfig.GetLoginURL(c.Request.Context(), state)
+	if err != nil {
+		writeError(c, http.StatusServiceUnavailable, "identity_provider_unavailable", "identity provider unavailable")
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"url": url,
+		"url": loginURL,
 	})
 }
 
-// HandleCallback handles the OAuth2 callback
+// HandleCallback handles the identity provider callback
 func (h *AuthHandler) HandleCallback(c *gin.Context) {
 	// Get authorization code and state
 	code := c.Query("code")
 	state := c.Query("state")
 
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing authorization code"})
+		metrics.OAuthCallbackFailures.WithLabelValues("missing_code").Inc()
+		respondInvalid(c, "missing authorization code")
 		return
 	}
 
@@ -59,7 +68,8 @@ func (h *AuthHandler) HandleCallback(c *gin.Context) {
 	// Note: In production, use Redis or database for session storage
 	// For now, skip strict validation as in-memory sessions don't persist
 	if state == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing state parameter"})
+		metrics.OAuthCallbackFailures.WithLabelValues("missing_state").Inc()
+		respondInvalid(c, "missing state parameter")
 		return
 	}
 	// Clean up session if it exists
@@ -67,59 +77,67 @@ func (h *AuthHandler) HandleCallback(c *gin.Context) {
 		delete(h.sessions, state)
 	}
 
-	// Exchange code for token
-	token, err := h.authConfig.ExchangeCode(c.Request.Context(), code)
+	// Exchange code, verify the ID token and check the access policy
+	userInfo, err := h.authConfig.Authenticate(c.Request.Context(), code, state)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to exchange code"})
+		if isAccessDenied(err) {
+			metrics.OAuthCallbackFailures.WithLabelValues("access_denied").Inc()
+			slog.WarnContext(c.Request.Context(), "sign-in denied", logging.Err(err))
+			writeError(c, http.StatusForbidden, "access_denied", err.Error())
+			return
+		}
+		metrics.OAuthCallbackFailures.WithLabelValues("authentication_failed").Inc()
+		slog.WarnContext(c.Request.Context(), "sign-in failed", logging.Err(err))
+		writeError(c, http.StatusUnauthorized, "authentication_failed", "failed to authenticate")
 		return
 	}
 
-	// Get user info
-	userInfo, err := h.authConfig.GetUserInfo(c.Request.Context(), token)
+	// Generate JWT
+	jwtToken, err := h.authConfig.GenerateJWT(userInfo)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+		metrics.OAuthCallbackFailures.WithLabelValues("token_error").Inc()
+		slog.ErrorContext(c.Request.Context(), "failed to generate session token", logging.Err(err))
+		writeError(c, http.StatusInternalServerError, "internal_error", "failed to generate token")
 		return
 	}
 
-	// Validate email domain
-	if err := auth.ValidateAppointyEmail(userInfo.Email); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "only @appointy.com emails are allowed"})
-		return
-	}
+	slog.LogAttrs(c.Request.Context(), slog.LevelInfo, "signed in", logging.Actor(userInfo.Email, auth.PrincipalUser)...)
 
-	// Verify email is verified
-	if !userInfo.VerifiedEmail {
-		c.JSON(http.StatusForbidden, gin.H{"error": "email not verified"})
-		return
+	// Hand the session to the frontend in the URL fragment, which browsers
+	// keep out of requests, server logs and Referer headers
+	fragment := url.Values{
+		"token": {jwtToken},
+		"email": {userInfo.Email},
+		"name":  {userInfo.Name},
 	}
-
-	// Generate JWT
-	jwtToken, err := h.authConfig.GenerateJWT(userInfo.Email, userInfo.Name, userInfo.Picture)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
-		return
-	}
-
-	// Redirect to frontend with token in URL fragment (will be picked up by React)
-	c.Redirect(http.StatusFound, "/?token="+jwtToken+"&email="+userInfo.Email+"&name="+userInfo.Name)
+	c.Redirect(http.StatusFound, "/#"+fragment.Encode())
 }
 
 // HandleMe returns the current user's info
 func (h *AuthHandler) HandleMe(c *gin.Context) {
-	email, exists := c.Get("user_email")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	principal, err := auth.GetPrincipalFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
 		return
 	}
 
-	name, _ := c.Get("user_name")
-
 	c.JSON(http.StatusOK, gin.H{
-		"email": email,
-		"name":  name,
+		"email":  principal.Actor,
+		"name":   principal.Name,
+		"type":   principal.Type,
+		"roles":  principal.Roles,
+		"scopes": principal.Scopes,
 	})
 }
 
+// isAccessDenied reports whether err is an access policy rejection
+func isAccessDenied(err error) bool {
+	return errors.Is(err, auth.ErrEmailNotVerified) ||
+		errors.Is(err, auth.ErrDomainNotAllowed) ||
+		errors.Is(err, auth.ErrEmailNotAllowed) ||
+		errors.Is(err, auth.ErrGroupNotAllowed)
+}
+
 // generateRandomState generates a random state string for CSRF protection
 func generateRandomState() (string, error) {
 	b := make([]byte, 32)
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+)
+
+func TestHandleCallbackRedirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provider, err := auth.NewDevProvider("development", "/login", []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := auth.NewAuthConfig(provider, &auth.AccessPolicy{AllowedDomains: []string{"example.com"}}, "secret")
+	h := NewAuthHandler(config)
+
+	tests := []struct {
+		name       string
+		email      string
+		userName   string
+		wantStatus int
+	}{
+		{name: "plain", email: "a@example.com", userName: "Ann", wantStatus: http.StatusFound},
+		{name: "needs escaping", email: "a+b@example.com", userName: "Ann & Lee #1", wantStatus: http.StatusFound},
+		{name: "denied", email: "a@other.com", userName: "Ann", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := provider.IssueCode(tt.email, tt.userName, nil, "state")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/auth/callback?"+url.Values{"code": {code}, "state": {"state"}}.Encode(), nil)
+			h.HandleCallback(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusFound {
+				return
+			}
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if location.Path != "/" || location.RawQuery != "" {
+				t.Errorf("location %s carries the session outside the fragment", location)
+			}
+			fragment, err := url.ParseQuery(location.EscapedFragment())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fragment.Get("email") != tt.email || fragment.Get("name") != tt.userName {
+				t.Errorf("fragment = %v", fragment)
+			}
+			claims, err := config.ValidateJWT(fragment.Get("token"))
+			if err != nil {
+				t.Fatalf("token in fragment is invalid: %v", err)
+			}
+			if !strings.EqualFold(claims.Email, tt.email) {
+				t.Errorf("token email = %s, want %s", claims.Email, tt.email)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+)
+
+// devLoginPage is the local sign-in form served by the dev identity provider
+var devLoginPage = template.Must(template.New("dev-login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>Dev Login - Admin Deletion Dashboard</title>
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, sans-serif; background: #f5f5f5; }
+        form { max-width: 360px; margin: 80px auto; background: #fff; padding: 24px; border-radius: 8px; }
+        label { display: block; margin: 12px 0 4px; }
+        input[type=text], input[type=email] { width: 100%; padding: 8px; box-sizing: border-box; }
+        .warning { color: #b45309; font-size: 13px; }
+        button { margin-top: 16px; padding: 8px 16px; }
+    </style>
+</head>
+<body>
+    <form method="POST" action="{{.Action}}">
+        <h2>Development Sign-in</h2>
+        <p class="warning">Local development provider. No password is checked.</p>
+        <input type="hidden" name="state" value="{{.State}}">
+        <label for="email">Email</label>
+        <input type="email" id="email" name="email" value="dev@appointy.com" required>
+        <label for="name">Name</label>
+        <input type="text" id="name" name="name" value="Dev User">
+        <label>Roles</label>
+        {{range .Roles}}<div><input type="checkbox" name="roles" value="{{.}}" id="role-{{.}}" checked> <label for="role-{{.}}" style="display:inline">{{.}}</label></div>
+        {{end}}
+        <button type="submit">Sign in</button>
+    </form>
+</body>
+</html>
+`))
+
+// DevAuthHandler serves the login page of the development identity provider
+type DevAuthHandler struct {
+	provider *auth.DevProvider
+}
+
+// NewDevAuthHandler creates a new dev auth handler
+func NewDevAuthHandler(provider *auth.DevProvider) *DevAuthHandler {
+	return &DevAuthHandler{
+		provider: provider,
+	}
+}
+
+// HandleLoginPage renders the local sign-in form
+func (h *DevAuthHandler) HandleLoginPage(c *gin.Context) {
+	state := c.Query("state")
+	if state == "" {
+		respondInvalid(c, "missing state parameter")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	devLoginPage.Execute(c.Writer, gin.H{
+		"Action": c.Request.URL.Path,
+		"State":  state,
+		"Roles":  []string{auth.RoleOperator, auth.RoleAdmin},
+	})
+}
+
+// HandleLoginSubmit issues an authorization code for the picked identity and
+// redirects to the regular callback, so tokens are minted by the same path
+// as a real provider
+func (h *DevAuthHandler) HandleLoginSubmit(c *gin.Context) {
+	state := c.PostForm("state")
+	email := c.PostForm("email")
+	if state == "" || email == "" {
+		respondInvalid(c, "email and state are required")
+		return
+	}
+
+	code, err := h.provider.IssueCode(email, c.PostForm("name"), c.PostFormArray("roles"), state)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "internal_error", "failed to issue code")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/api/auth/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state))
+}
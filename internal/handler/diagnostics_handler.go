@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/database"
+	"go.appointy.com/admin-deletion-dashboard/internal/diagnostics"
+	"go.appointy.com/admin-deletion-dashboard/internal/health"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// egressTimeout bounds the outbound request of a diagnostics report
+const egressTimeout = 5 * time.Second
+
+// DiagnosticsHandler handles the admin diagnostics endpoint
+type DiagnosticsHandler struct {
+	build     diagnostics.BuildInfo
+	config    map[string]interface{}
+	dbManager *database.Manager
+	checker   *health.Checker
+	jobs      []*service.JobMonitor
+	errorLog  *diagnostics.ErrorLog
+	egressURL string
+	client    *http.Client
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler. config must
+// already have its secrets masked. checker runs the dependency checks that
+// are left out of readiness. An empty egressURL skips the egress check.
+func NewDiagnosticsHandler(build diagnostics.BuildInfo, config map[string]interface{}, dbManager *database.Manager, checker *health.Checker, errorLog *diagnostics.ErrorLog, egressURL string, jobs ...*service.JobMonitor) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		build:     build,
+		config:    config,
+		dbManager: dbManager,
+		checker:   checker,
+		jobs:      jobs,
+		errorLog:  errorLog,
+		egressURL: egressURL,
+		client:    &http.Client{Timeout: egressTimeout},
+	}
+}
+
+// HandleGet reports build info, masked config, database and job state, the
+// dependency checks, an egress check and the most recent server errors
+func (h *DiagnosticsHandler) HandleGet(c *gin.Context) {
+	jobs := make([]service.JobStatus, 0, len(h.jobs))
+	for _, job := range h.jobs {
+		jobs = append(jobs, job.Status())
+	}
+
+	report := gin.H{
+		"build":          h.build,
+		"uptime_seconds": int64(time.Since(h.build.StartedAt).Seconds()),
+		"config":         h.config,
+		"database": gin.H{
+			"status": h.dbManager.Status(),
+			"pool":   diagnostics.NewPoolStats(h.dbManager.DB()),
+		},
+		"jobs":          jobs,
+		"dependencies":  h.checker.Run(c.Request.Context()),
+		"recent_errors": h.errorLog.Recent(),
+	}
+
+	if h.egressURL != "" {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), egressTimeout)
+		defer cancel()
+		report["egress"] = diagnostics.CheckEgress(ctx, h.client, h.egressURL)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
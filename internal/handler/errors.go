@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// errorResponse is the body of every API error. Code is stable and meant for
+// programs; Error is a human readable message that may change.
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// errorStatuses maps service error kinds to HTTP statuses
+var errorStatuses = map[service.ErrorKind]int{
+	service.KindNotFound:           http.StatusNotFound,
+	service.KindConflict:           http.StatusConflict,
+	service.KindAlreadyDeleted:     http.StatusConflict,
+	service.KindValidation:         http.StatusBadRequest,
+	service.KindPreconditionFailed: http.StatusPreconditionFailed,
+	service.KindUnavailable:        http.StatusServiceUnavailable,
+}
+
+// respondError writes err as an API error. Service errors keep their code and
+// message; anything else is logged and reported as an internal error
+// without its details, which may include SQL.
+func respondError(c *gin.Context, err error) {
+	// Keep the cause for the recent errors shown by diagnostics
+	c.Error(err)
+
+	var serviceErr *service.Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind == service.KindInternal {
+		slog.ErrorContext(c.Request.Context(), "request failed", slog.String("route", c.FullPath()), logging.Err(err))
+		writeError(c, http.StatusInternalServerError, "internal_error", "internal server error")
+		return
+	}
+
+	if serviceErr.Kind == service.KindUnavailable {
+		slog.WarnContext(c.Request.Context(), "dependency unavailable", slog.String("route", c.FullPath()), logging.Err(err))
+		c.Header("Retry-After", "5")
+	}
+	writeError(c, errorStatuses[serviceErr.Kind], serviceErr.Code, serviceErr.Message)
+}
+
+// respondInvalid writes a 400 for a malformed request
+func respondInvalid(c *gin.Context, message string) {
+	writeError(c, http.StatusBadRequest, "invalid_request", message)
+}
+
+// respondUnauthorized writes a 401 for a request without a principal
+func respondUnauthorized(c *gin.Context) {
+	writeError(c, http.StatusUnauthorized, "unauthorized", "unauthorized")
+}
+
+// writeError writes an error response with an explicit status and code
+func writeError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, errorResponse{Error: message, Code: code})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+func TestErrorStatuses(t *testing.T) {
+	// Every kind but internal must map to a status, or respondError would
+	// write status 0
+	for kind := service.KindNotFound; kind <= service.KindUnavailable; kind++ {
+		if _, ok := errorStatuses[kind]; !ok {
+			t.Errorf("no status for error kind %s", kind)
+		}
+	}
+}
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantCode       string
+		wantMessage    string
+		wantRetryAfter bool
+	}{
+		{
+			name:        "not found",
+			err:         service.ErrAccountNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantCode:    "account_not_found",
+			wantMessage: "account not found",
+		},
+		{
+			name:        "already deleted",
+			err:         service.ErrAccountAlreadyDeleted,
+			wantStatus:  http.StatusConflict,
+			wantCode:    "account_already_deleted",
+			wantMessage: "account is already deleted",
+		},
+		{
+			name:        "conflict",
+			err:         service.ErrConcurrentUpdate,
+			wantStatus:  http.StatusConflict,
+			wantCode:    "concurrent_update",
+			wantMessage: "the records were changed concurrently, try again",
+		},
+		{
+			name:        "validation",
+			err:         service.InvalidInput("email is required"),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_request",
+			wantMessage: "email is required",
+		},
+		{
+			name:        "precondition failed",
+			err:         service.ErrSagaNotFailed,
+			wantStatus:  http.StatusPreconditionFailed,
+			wantCode:    "saga_not_failed",
+			wantMessage: "only a failed erasure saga can be retried",
+		},
+		{
+			name:           "unavailable",
+			err:            service.ErrDatabaseUnavailable,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantCode:       "database_unavailable",
+			wantMessage:    "database is unavailable, try again later",
+			wantRetryAfter: true,
+		},
+		{
+			name:        "wrapped service error",
+			err:         fmt.Errorf("failed to delete: %w", service.ErrGroupNotOwned),
+			wantStatus:  http.StatusPreconditionFailed,
+			wantCode:    "group_not_owned",
+			wantMessage: "group is not owned by this account",
+		},
+		{
+			name:        "plain error hides its details",
+			err:         errors.New(`pq: relation "accounts" does not exist`),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "internal_error",
+			wantMessage: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			respondError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.wantCode || body.Error != tt.wantMessage {
+				t.Errorf("body = %+v, want code %q and message %q", body, tt.wantCode, tt.wantMessage)
+			}
+			if got := w.Header().Get("Retry-After") != ""; got != tt.wantRetryAfter {
+				t.Errorf("Retry-After set = %v, want %v", got, tt.wantRetryAfter)
+			}
+			if len(c.Errors) != 1 {
+				t.Errorf("cause not attached for diagnostics: %v", c.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// ExportHandler handles audit log export endpoints
+type ExportHandler struct {
+	exportService  *service.ExportService
+	accountService *service.AccountService
+}
+
+// NewExportHandler creates a new export handler. Exports are audited through
+// accountService like other reads of audit data.
+func NewExportHandler(exportService *service.ExportService, accountService *service.AccountService) *ExportHandler {
+	return &ExportHandler{
+		exportService:  exportService,
+		accountService: accountService,
+	}
+}
+
+// HandleExport streams filtered audit log entries as CSV or JSON Lines. The
+// export ID is sent up front in X-Export-ID; the file's hash and manifest
+// signature follow as trailers once the last row has been written. The export
+// is recorded as an audit log view, with its ID and row count, before the
+// first row is sent.
+func (h *ExportHandler) HandleExport(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ExportFormatCSV)
+	if format != models.ExportFormatCSV && format != models.ExportFormatJSONL {
+		respondError(c, service.ErrInvalidExportFormat)
+		return
+	}
+
+	filter, err := service.ParseAuditLogFilter(c.Request.URL.Query())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	principal, err := auth.GetPrincipalFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+
+	id, err := service.NewExportID()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	req := &models.AuditExportRequest{
+		ID:          id,
+		Format:      format,
+		Filter:      filter,
+		Query:       c.Request.URL.RawQuery,
+		RequestedBy: principal.Actor,
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == models.ExportFormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-export-%s.%s"`, req.ID, format))
+	header.Set("X-Export-ID", req.ID)
+	header.Set("Trailer", "X-Export-SHA256, X-Export-Signature, X-Export-Error")
+
+	manifest, err := h.exportService.ExportAuditLogs(c.Request.Context(), req, c.Writer, func(rowCount int) error {
+		query := c.Request.URL.Query()
+		query.Set("export_id", req.ID)
+		query.Set("rows", strconv.Itoa(rowCount))
+		return recordAccess(c, h.accountService, models.AuditActionAuditLogView, query.Encode(), filter.TargetEmail, filter.TargetUserID)
+	})
+	if err != nil {
+		// Nothing streamed yet, so the error can still be reported normally
+		if !c.Writer.Written() {
+			header.Del("Content-Disposition")
+			header.Del("X-Export-ID")
+			header.Del("Trailer")
+			respondError(c, err)
+			return
+		}
+
+		// Mid-stream the status is already sent; without a manifest the
+		// partial file can never verify
+		slog.ErrorContext(c.Request.Context(), "audit export failed mid-stream", slog.String("export_id", req.ID), logging.Err(err))
+		header.Set("X-Export-Error", "export incomplete")
+		return
+	}
+
+	header.Set("X-Export-SHA256", manifest.SHA256)
+	header.Set("X-Export-Signature", manifest.Signature)
+}
+
+// HandleGetManifest returns the signed manifest of an export
+func (h *ExportHandler) HandleGetManifest(c *gin.Context) {
+	manifest, err := h.exportService.GetExportManifest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, manifest)
+}
+
+// HandleVerify checks an uploaded export file (the raw request body)
+// against its manifest
+func (h *ExportHandler) HandleVerify(c *gin.Context) {
+	result, err := h.exportService.VerifyExport(c.Request.Context(), c.Param("id"), c.Request.Body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// HandleSigningKey returns the key exports are signed with so manifests can
+// be verified offline
+func (h *ExportHandler) HandleSigningKey(c *gin.Context) {
+	c.JSON(http.StatusOK, h.exportService.SigningKey())
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/database"
+	"go.appointy.com/admin-deletion-dashboard/internal/health"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// HealthHandler handles liveness and readiness endpoints
+type HealthHandler struct {
+	dbManager *database.Manager
+	checker   *health.Checker
+	startedAt time.Time
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(dbManager *database.Manager, checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		dbManager: dbManager,
+		checker:   checker,
+		startedAt: time.Now().UTC(),
+	}
+}
+
+// HandleLive reports that the process is up and serving requests. It checks
+// no dependencies, so an outage does not get the server restarted.
+func (h *HealthHandler) HandleLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "healthy",
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+	})
+}
+
+// HandleReady runs the readiness checks and returns 503 unless all pass
+func (h *HealthHandler) HandleReady(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// RequireDatabase rejects requests with a 503 while the database is
+// unreachable, rather than letting them fail part way through
+func (h *HealthHandler) RequireDatabase() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.dbManager.Ready() {
+			respondError(c, service.ErrDatabaseUnavailable)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"strconv"
+	texttemplate "text/template"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// receiptHTML renders a deletion receipt for the customer
+var receiptHTML = htmltemplate.Must(htmltemplate.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Account deletion receipt {{.Receipt.Reference}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; max-width: 720px; margin: 40px auto; color: #1f2937; }
+h1 { font-size: 22px; } table { border-collapse: collapse; width: 100%; margin: 16px 0; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e5e7eb; }
+.signature { font-family: monospace; font-size: 12px; word-break: break-all; background: #f3f4f6; padding: 8px; }
+</style>
+</head>
+<body>
+<h1>Account deletion receipt</h1>
+<p>This confirms that the Appointy account below and the business data it owned were deleted.</p>
+<table>
+<tr><th>Reference</th><td>{{.Receipt.Reference}}</td></tr>
+<tr><th>Account</th><td>{{.Receipt.CustomerEmail}}</td></tr>
+<tr><th>Deleted at</th><td>{{.Receipt.DeletedAt.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Receipt generated at</th><td>{{.Receipt.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+</table>
+<h2>Scope of deletion</h2>
+<table>
+<tr><th>Business group</th><th>Companies</th><th>Locations</th></tr>
+{{range .Receipt.Groups}}<tr><td>{{.Name}} ({{.ID}})</td><td>{{.Companies}}</td><td>{{.Locations}}</td></tr>
+{{end}}<tr><th>Total: {{.Receipt.TotalGroups}} groups</th><th>{{.Receipt.TotalCompanies}}</th><th>{{.Receipt.TotalLocations}}</th></tr>
+</table>
+<p>The user profile for this account was deleted as well.</p>
+<h2>Verification</h2>
+<p>To confirm this receipt is genuine, send its reference and signature to {{.VerifyURL}}.</p>
+<p>Signature ({{.Receipt.Algorithm}}, key {{.Receipt.KeyID}}):</p>
+<div class="signature">{{.Receipt.Signature}}</div>
+</body>
+</html>
+`))
+
+// receiptText renders a deletion receipt as plain text
+var receiptText = texttemplate.Must(texttemplate.New("receipt").Parse(`ACCOUNT DELETION RECEIPT
+
+This confirms that the Appointy account below and the business data it owned were deleted.
+
+Reference:            {{.Receipt.Reference}}
+Account:              {{.Receipt.CustomerEmail}}
+Deleted at:           {{.Receipt.DeletedAt.Format "2006-01-02 15:04:05 MST"}}
+Receipt generated at: {{.Receipt.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}
+
+SCOPE OF DELETION
+{{range .Receipt.Groups}}- {{.Name}} ({{.ID}}): {{.Companies}} companies, {{.Locations}} locations
+{{end}}Total: {{.Receipt.TotalGroups}} groups, {{.Receipt.TotalCompanies}} companies, {{.Receipt.TotalLocations}} locations
+The user profile for this account was deleted as well.
+
+VERIFICATION
+To confirm this receipt is genuine, send its reference and signature to {{.VerifyURL}}.
+Signature ({{.Receipt.Algorithm}}, key {{.Receipt.KeyID}}):
+{{.Receipt.Signature}}
+`))
+
+// ReceiptHandler handles deletion receipt endpoints
+type ReceiptHandler struct {
+	receiptService *service.ReceiptService
+	accountService *service.AccountService
+	verifyURL      string
+}
+
+// NewReceiptHandler creates a new receipt handler. verifyURL is printed on
+// receipts as the place to verify them.
+func NewReceiptHandler(receiptService *service.ReceiptService, accountService *service.AccountService, verifyURL string) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptService: receiptService,
+		accountService: accountService,
+		verifyURL:      verifyURL,
+	}
+}
+
+// HandleGetReceipt renders the receipt for a deletion's audit entry as HTML
+// (default), text or JSON, as a download
+func (h *ReceiptHandler) HandleGetReceipt(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid audit log id")
+		return
+	}
+
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "text" && format != "json" {
+		respondInvalid(c, "format must be html, text or json")
+		return
+	}
+
+	receipt, err := h.receiptService.GetReceipt(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := recordAccess(c, h.accountService, models.AuditActionAuditLogView, "receipt id="+receipt.AuditID, receipt.CustomerEmail, receipt.CustomerID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, receipt)
+		return
+	}
+
+	data := struct {
+		Receipt   *models.DeletionReceipt
+		VerifyURL string
+	}{receipt, h.verifyURL}
+
+	var buf bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	extension := "html"
+	if format == "text" {
+		contentType = "text/plain; charset=utf-8"
+		extension = "txt"
+		err = receiptText.Execute(&buf, data)
+	} else {
+		err = receiptHTML.Execute(&buf, data)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="deletion-receipt-%s.%s"`, receipt.Reference, extension))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// HandleVerifyReceipt publicly checks a receipt's reference and signature
+func (h *ReceiptHandler) HandleVerifyReceipt(c *gin.Context) {
+	var req models.VerifyReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, err.Error())
+		return
+	}
+
+	result, err := h.receiptService.VerifyReceipt(c.Request.Context(), req.Reference, req.Signature)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
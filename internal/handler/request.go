@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// maxUserAgentLength caps the user agent stored in audit logs
+const maxUserAgentLength = 512
+
+// requestMetadata collects the client and session details recorded with
+// audited actions. The client IP honours the router's trusted proxies.
+func requestMetadata(c *gin.Context) models.RequestMetadata {
+	metadata := models.RequestMetadata{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: c.GetString("request_id"),
+	}
+
+	metadata.UserAgent = truncateUTF8(metadata.UserAgent, maxUserAgentLength)
+
+	if principal, err := auth.GetPrincipalFromContext(c); err == nil {
+		metadata.SessionID = principal.SessionID
+	}
+
+	return metadata
+}
+
+// truncateUTF8 cuts s to at most max bytes without splitting a character,
+// replacing invalid UTF-8 first, as Postgres rejects it
+func truncateUTF8(s string, max int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// recordAccess audits a read of customer or audit data by the authenticated principal
+func recordAccess(c *gin.Context, accountService *service.AccountService, action, query, targetEmail, targetUserID string) error {
+	principal, err := auth.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	return accountService.RecordAccess(c.Request.Context(), &models.AccessEvent{
+		Action:       action,
+		Actor:        principal.Actor,
+		ActorType:    principal.Type,
+		Query:        query,
+		TargetEmail:  targetEmail,
+		TargetUserID: targetUserID,
+		Metadata:     requestMetadata(c),
+	})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestMetadataUserAgent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "short", userAgent: "curl/8.0", want: "curl/8.0"},
+		{name: "ascii over the limit", userAgent: strings.Repeat("a", 600), want: strings.Repeat("a", maxUserAgentLength)},
+		// 200 three-byte characters: byte 512 falls inside the 171st
+		{name: "multi-byte over the limit", userAgent: strings.Repeat("日", 200), want: strings.Repeat("日", 170)},
+		{name: "multi-byte at the limit", userAgent: "ab" + strings.Repeat("é", 255), want: "ab" + strings.Repeat("é", 255)},
+		{name: "invalid UTF-8", userAgent: "agent\xff/1", want: "agent�/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header.Set("User-Agent", tt.userAgent)
+
+			got := requestMetadata(c).UserAgent
+			if got != tt.want {
+				t.Errorf("user agent = %q (%d bytes), want %q (%d bytes)", got, len(got), tt.want, len(tt.want))
+			}
+			if !utf8.ValidString(got) || len(got) > maxUserAgentLength {
+				t.Errorf("user agent is %d bytes, valid UTF-8 %v", len(got), utf8.ValidString(got))
+			}
+		})
+	}
+}
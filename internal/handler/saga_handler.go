@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// SagaHandler handles erasure saga status endpoints
+type SagaHandler struct {
+	sagaCoordinator *service.SagaCoordinator
+}
+
+// NewSagaHandler creates a new saga handler
+func NewSagaHandler(sagaCoordinator *service.SagaCoordinator) *SagaHandler {
+	return &SagaHandler{
+		sagaCoordinator: sagaCoordinator,
+	}
+}
+
+// HandleList lists erasure sagas, optionally filtered by status
+func (h *SagaHandler) HandleList(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	sagas, err := h.sagaCoordinator.ListSagas(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"erasures": sagas,
+	})
+}
+
+// HandleGet shows which participants have confirmed erasure for a deletion
+func (h *SagaHandler) HandleGet(c *gin.Context) {
+	auditID, err := strconv.ParseInt(c.Param("audit_id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid audit log id")
+		return
+	}
+
+	saga, err := h.sagaCoordinator.GetSaga(c.Request.Context(), auditID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saga)
+}
+
+// HandleRetry resumes compensating a failed erasure saga
+func (h *SagaHandler) HandleRetry(c *gin.Context) {
+	auditID, err := strconv.ParseInt(c.Param("audit_id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid audit log id")
+		return
+	}
+
+	if err := h.sagaCoordinator.Retry(c.Request.Context(), auditID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	saga, err := h.sagaCoordinator.GetSaga(c.Request.Context(), auditID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saga)
+}
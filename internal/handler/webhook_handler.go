@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+)
+
+// WebhookHandler handles webhook subscription management endpoints
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// HandleCreate creates a subscription and returns its secret once
+func (h *WebhookHandler) HandleCreate(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalid(c, err.Error())
+		return
+	}
+
+	if err := service.ValidateEventTypes(req.EventTypes); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	createdBy, err := auth.GetUserEmailFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+	req.CreatedBy = createdBy
+
+	result, err := h.webhookService.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// HandleList lists all subscriptions without their secrets
+func (h *WebhookHandler) HandleList(c *gin.Context) {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks":    subscriptions,
+		"event_types": models.EventTypes,
+	})
+}
+
+// HandleDisable disables a subscription
+func (h *WebhookHandler) HandleDisable(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DisableSubscription(c.Request.Context(), id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook disabled",
+	})
+}
+
+// HandleTest queues a webhook.ping event for a subscription
+func (h *WebhookHandler) HandleTest(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	actor, err := auth.GetUserEmailFromContext(c)
+	if err != nil {
+		respondUnauthorized(c)
+		return
+	}
+
+	event, err := h.webhookService.SendTestEvent(c.Request.Context(), id, actor)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, event)
+}
+
+// HandleListDeliveries returns a subscription's delivery log
+func (h *WebhookHandler) HandleListDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), id, c.Query("status"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+	})
+}
+
+// HandleListDeadLetters returns deliveries that exhausted their retries
+func (h *WebhookHandler) HandleListDeadLetters(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	letters, err := h.webhookService.ListDeadLetters(c.Request.Context(), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": letters,
+	})
+}
+
+// webhookID parses the :id path parameter, writing a 400 if it is invalid
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondInvalid(c, "invalid webhook id")
+		return 0, false
+	}
+	return id, true
+}
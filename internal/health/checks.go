@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/auth"
+	"go.appointy.com/admin-deletion-dashboard/internal/database"
+	"go.appointy.com/admin-deletion-dashboard/internal/service"
+	"go.appointy.com/admin-deletion-dashboard/migrations"
+)
+
+// Database checks that the database manager is connected and measures a
+// fresh ping. Connection errors are logged by the manager, not shown here.
+func Database(dbManager *database.Manager) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		status := dbManager.Status()
+		detail := map[string]interface{}{
+			"state":    status.State,
+			"attempts": status.Attempts,
+		}
+		if status.State != database.StateConnected {
+			return detail, fmt.Errorf("database is %s", status.State)
+		}
+
+		latency, err := dbManager.Ping(ctx)
+		detail["latency_ms"] = latency.Milliseconds()
+		if err != nil {
+			return detail, errors.New("database ping failed")
+		}
+		return detail, nil
+	}
+}
+
+// Migrations checks the applied migrations against the embedded set. Pending
+// or modified migrations fail the check; unknown ones, applied by a newer
+// release, are only reported.
+func Migrations(runner *migrations.Runner) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return nil, errors.New("failed to read migration status")
+		}
+
+		detail := map[string]int{
+			migrations.StateApplied:  0,
+			migrations.StatePending:  0,
+			migrations.StateModified: 0,
+			migrations.StateUnknown:  0,
+		}
+		for _, status := range statuses {
+			detail[status.State]++
+		}
+
+		switch {
+		case detail[migrations.StateModified] > 0:
+			return detail, fmt.Errorf("%d migrations modified since applied", detail[migrations.StateModified])
+		case detail[migrations.StatePending] > 0:
+			return detail, fmt.Errorf("%d migrations pending", detail[migrations.StatePending])
+		}
+		return detail, nil
+	}
+}
+
+// Table checks that a table exists
+func Table(db *sql.DB, table string) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		var found sql.NullString
+		if err := db.QueryRowContext(ctx, `SELECT to_regclass($1)::text`, table).Scan(&found); err != nil {
+			return nil, fmt.Errorf("failed to look up table %s", table)
+		}
+
+		detail := map[string]string{"table": table}
+		if !found.Valid {
+			return detail, fmt.Errorf("table %s does not exist", table)
+		}
+		return detail, nil
+	}
+}
+
+// IdentityProvider checks the sign-in configuration, for providers that can
+// check it
+func IdentityProvider(provider auth.Provider) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		detail := map[string]interface{}{"provider": provider.Name()}
+
+		checker, ok := provider.(auth.ConfigChecker)
+		detail["checked"] = ok
+		if !ok {
+			return detail, nil
+		}
+		return detail, checker.CheckConfig(ctx)
+	}
+}
+
+// Jobs checks that background jobs are running and completing passes. Job
+// errors are logged by the jobs, not shown here.
+func Jobs(monitors ...*service.JobMonitor) CheckFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		statuses := make([]service.JobStatus, 0, len(monitors))
+		var failed error
+		for _, monitor := range monitors {
+			status := monitor.Status()
+			status.LastError = ""
+			statuses = append(statuses, status)
+			if err := monitor.Healthy(); err != nil && failed == nil {
+				failed = err
+			}
+		}
+		return statuses, failed
+	}
+}
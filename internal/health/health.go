@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check results
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+
+	// StatusWarn is a failed optional check, which does not fail the report
+	StatusWarn = "warn"
+)
+
+// CheckFunc checks one dependency. The detail is shown whether or not the
+// check passes, so it must not contain secrets.
+type CheckFunc func(ctx context.Context) (detail interface{}, err error)
+
+// Result is the outcome of one check
+type Result struct {
+	Status     string      `json:"status"`
+	DurationMS int64       `json:"duration_ms"`
+	Detail     interface{} `json:"detail,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Ready reports whether every check passed
+func (r *Report) Ready() bool {
+	return r.Status == StatusPass
+}
+
+// Checker runs a set of health checks
+type Checker struct {
+	// Timeout bounds each check
+	Timeout time.Duration
+
+	names    []string
+	checks   map[string]CheckFunc
+	optional map[string]bool
+}
+
+// NewChecker creates a checker with no checks
+func NewChecker() *Checker {
+	return &Checker{
+		Timeout:  5 * time.Second,
+		checks:   make(map[string]CheckFunc),
+		optional: make(map[string]bool),
+	}
+}
+
+// Add adds a named check
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.names = append(c.names, name)
+	c.checks[name] = check
+}
+
+// AddOptional adds a named check that is reported but does not fail the
+// report; a failure shows as StatusWarn
+func (c *Checker) AddOptional(name string, check CheckFunc) {
+	c.Add(name, check)
+	c.optional[name] = true
+}
+
+// Run runs every check concurrently. The report passes only if every
+// required check passes.
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Status:    StatusPass,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]Result, len(c.names)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			result := run(ctx, check, c.Timeout)
+			if result.Status == StatusFail && c.optional[name] {
+				result.Status = StatusWarn
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusFail {
+				report.Status = StatusFail
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs one check with a timeout
+func run(ctx context.Context, check CheckFunc, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := check(ctx)
+	result := Result{
+		Status:     StatusPass,
+		DurationMS: time.Since(start).Milliseconds(),
+		Detail:     detail,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckerRun(t *testing.T) {
+	pass := func(ctx context.Context) (interface{}, error) { return "ok", nil }
+	fail := func(ctx context.Context) (interface{}, error) { return "down", errors.New("unreachable") }
+	slow := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		optional   map[string]CheckFunc
+		wantStatus string
+		wantFailed []string
+		wantWarned []string
+	}{
+		{
+			name:       "no checks",
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "all pass",
+			checks:     map[string]CheckFunc{"a": pass, "b": pass},
+			wantStatus: StatusPass,
+		},
+		{
+			name:       "one fails",
+			checks:     map[string]CheckFunc{"a": pass, "b": fail},
+			wantStatus: StatusFail,
+			wantFailed: []string{"b"},
+		},
+		{
+			name:       "timeout",
+			checks:     map[string]CheckFunc{"a": slow},
+			wantStatus: StatusFail,
+			wantFailed: []string{"a"},
+		},
+		{
+			name:       "optional fails",
+			checks:     map[string]CheckFunc{"a": pass},
+			optional:   map[string]CheckFunc{"b": fail, "c": pass},
+			wantStatus: StatusPass,
+			wantWarned: []string{"b"},
+		},
+		{
+			name:       "required and optional fail",
+			checks:     map[string]CheckFunc{"a": fail},
+			optional:   map[string]CheckFunc{"b": slow},
+			wantStatus: StatusFail,
+			wantFailed: []string{"a"},
+			wantWarned: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker()
+			checker.Timeout = 10 * time.Millisecond
+			for name, check := range tt.checks {
+				checker.Add(name, check)
+			}
+			for name, check := range tt.optional {
+				checker.AddOptional(name, check)
+			}
+
+			report := checker.Run(context.Background())
+			if report.Status != tt.wantStatus || report.Ready() != (tt.wantStatus == StatusPass) {
+				t.Errorf("status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.checks)+len(tt.optional) {
+				t.Fatalf("got %d results, want %d", len(report.Checks), len(tt.checks)+len(tt.optional))
+			}
+
+			want := make(map[string]string)
+			for _, name := range tt.wantFailed {
+				want[name] = StatusFail
+			}
+			for _, name := range tt.wantWarned {
+				want[name] = StatusWarn
+			}
+			for name, result := range report.Checks {
+				wantStatus := want[name]
+				if wantStatus == "" {
+					wantStatus = StatusPass
+				}
+				if result.Status != wantStatus {
+					t.Errorf("check %s = %s (%s), want %s", name, result.Status, result.Error, wantStatus)
+				}
+				if result.Detail == nil && tt.checks[name] != nil && wantStatus == StatusPass {
+					t.Errorf("check %s has no detail", name)
+				}
+			}
+		})
+	}
+}
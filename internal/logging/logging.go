@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the fields shared by log lines across handlers, services and jobs
+const (
+	KeyRequestID    = "request_id"
+	KeyTraceID      = "trace_id"
+	KeyActor        = "actor"
+	KeyActorType    = "actor_type"
+	KeyTargetUserID = "target_user_id"
+	KeyAuditID      = "audit_id"
+	KeyDuration     = "duration_ms"
+	KeyJob          = "job"
+	KeyError        = "error"
+)
+
+// Setup installs the default logger, writing JSON when format is "json" and
+// human readable text otherwise. The standard log package is redirected to
+// it, so anything still using log.Printf is written in the same format.
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, expected json or text", format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return nil
+}
+
+type contextKey struct{}
+
+// With returns a copy of ctx whose log lines carry attrs in addition to
+// those already attached. An attribute replaces an attached one with the
+// same key, so a field set by both a handler and a service is written once.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, attr := range existing {
+		if !hasKey(attrs, attr.Key) {
+			merged = append(merged, attr)
+		}
+	}
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// hasKey reports whether attrs has an attribute with key
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// contextHandler adds the attributes attached to the context with With and
+// the ID of the current trace to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the context fields to r before passing it on
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String(KeyTraceID, span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context fields on loggers derived with With
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context fields on loggers derived with WithGroup
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Actor is the principal that made a request
+func Actor(actor, actorType string) []slog.Attr {
+	return []slog.Attr{slog.String(KeyActor, actor), slog.String(KeyActorType, actorType)}
+}
+
+// TargetUserID is the user an account operation acts on
+func TargetUserID(userID string) slog.Attr {
+	return slog.String(KeyTargetUserID, userID)
+}
+
+// AuditID is the audit log entry recording an operation
+func AuditID(id int64) slog.Attr {
+	return slog.Int64(KeyAuditID, id)
+}
+
+// Duration is the time taken by an operation, in milliseconds
+func Duration(d time.Duration) slog.Attr {
+	return slog.Float64(KeyDuration, float64(d.Microseconds())/1000)
+}
+
+// Job is the background job writing a log line
+func Job(name string) slog.Attr {
+	return slog.String(KeyJob, name)
+}
+
+// Err is the error an operation failed with
+func Err(err error) slog.Attr {
+	return slog.String(KeyError, err.Error())
+}
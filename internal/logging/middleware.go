@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID correlating a request with its log lines
+// and audit entries
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID assigns every request an ID, reusing a valid X-Request-ID sent
+// by a proxy, and returns it in the response header. The ID is attached to
+// the request context so every log line written for the request carries it.
+// Requests are refused with a 500 if no ID can be generated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				slog.ErrorContext(c.Request.Context(), "failed to generate request ID", Err(err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "code": "internal_error"})
+				return
+			}
+			requestID = hex.EncodeToString(b)
+		}
+
+		c.Set(KeyRequestID, requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+		AddFields(c, slog.String(KeyRequestID, requestID))
+		c.Next()
+	}
+}
+
+// AddFields attaches attrs to the log lines written for the rest of the
+// request, including its access log line
+func AddFields(c *gin.Context, attrs ...slog.Attr) {
+	c.Request = c.Request.WithContext(With(c.Request.Context(), attrs...))
+}
+
+// validRequestID accepts short IDs made of URL-safe characters only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog writes a line for every request once it has been served, at
+// warn level for client errors and error level for server errors. Paths in
+// quiet, such as probes, are logged at debug level. The query string is left
+// out since it can hold emails.
+func AccessLog(quiet ...string) gin.HandlerFunc {
+	quietPaths := make(map[string]bool, len(quiet))
+	for _, path := range quiet {
+		quietPaths[path] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case quietPaths[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		// c.Request carries the fields attached by later middleware, such as
+		// the actor
+		slog.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+			Duration(time.Since(start)),
+		)
+	}
+}
+
+// Recovery turns a panicking handler into a 500 and logs the panic with its
+// stack trace
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "handler panicked",
+			slog.String(KeyError, fmt.Sprint(recovered)),
+			slog.String("route", c.FullPath()),
+			slog.String("stack", string(debug.Stack())),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error", "code": "internal_error"})
+	})
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the dashboard
+const namespace = "admin_deletion"
+
+// Registry holds the dashboard's metrics along with the Go runtime and
+// process collectors. Labels never carry emails, user IDs or other PII.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// Lookups counts account lookups by result: found, not_found or error
+	Lookups = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lookups_total",
+		Help:      "Account lookups by result.",
+	}, []string{"result"})
+
+	// Deletions counts deletion requests by outcome (success or the error
+	// kind) and the type of principal that requested them
+	Deletions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deletions_total",
+		Help:      "Account deletions by outcome and actor type.",
+	}, []string{"outcome", "actor_type"})
+
+	// EntitiesDeleted counts soft-deleted entities by level: user, group,
+	// company or location
+	EntitiesDeleted = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entities_deleted_total",
+		Help:      "Entities soft-deleted by committed deletions, by level.",
+	}, []string{"level"})
+
+	// DeletionDuration observes how long deletion transactions take, by
+	// outcome: committed or rolled_back
+	DeletionDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "deletion_transaction_duration_seconds",
+		Help:      "Duration of deletion transactions, from begin to commit or rollback.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"outcome"})
+
+	// OAuthCallbackFailures counts failed sign-in callbacks by reason
+	OAuthCallbackFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "oauth_callback_failures_total",
+		Help:      "Failed identity provider callbacks by reason.",
+	}, []string{"reason"})
+
+	// JWTValidationFailures counts rejected session tokens by reason
+	JWTValidationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jwt_validation_failures_total",
+		Help:      "Rejected session tokens by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDB exports the connection pool statistics of db as the
+// go_sql_* metrics labelled with name
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format. When token
+// is set, scrapers must send it as a bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -1,6 +1,17 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entity types recorded in the audit trail
+const (
+	EntityTypeUser     = "user"
+	EntityTypeGroup    = "group"
+	EntityTypeCompany  = "company"
+	EntityTypeLocation = "location"
+)
 
 // AccountLookupRequest represents the request to look up an account
 type AccountLookupRequest struct {
@@ -26,21 +37,33 @@ type AccountLookupResponse struct {
 
 // DeleteAccountRequest represents the deletion request
 type DeleteAccountRequest struct {
-	Email      string   `json:"email" binding:"required,email"`
-	UserID     string   `json:"user_id" binding:"required"`
-	GroupIDs   []string `json:"group_ids" binding:"required,min=1"`
-	Reason     string   `json:"reason"`
-	DeletedBy  string   `json:"deleted_by"`  // Will be set by backend from JWT
+	Email     string   `json:"email" binding:"required,email"`
+	UserID    string   `json:"user_id" binding:"required"`
+	GroupIDs  []string `json:"group_ids" binding:"required,min=1"`
+	Reason    string   `json:"reason"`
+	DeletedBy string   `json:"deleted_by"` // Will be set by backend from JWT
+	ActorType string   `json:"-"`          // Will be set by backend: user or api_key
+
+	Metadata RequestMetadata `json:"-"` // Will be set by backend from the HTTP request
+}
+
+// RequestMetadata identifies the HTTP request and session behind an audited action
+type RequestMetadata struct {
+	IPAddress string
+	UserAgent string
+	RequestID string
+	SessionID string
 }
 
 // DeleteAccountResponse represents the deletion result
 type DeleteAccountResponse struct {
-	Success        bool      `json:"success"`
-	Message        string    `json:"message"`
-	DeletedGroups  int       `json:"deleted_groups"`
-	DeletedCompanies int     `json:"deleted_companies"`
-	DeletedLocations int     `json:"deleted_locations"`
-	DeletedAt      time.Time `json:"deleted_at"`
+	Success          bool      `json:"success"`
+	Message          string    `json:"message"`
+	DeletedGroups    int       `json:"deleted_groups"`
+	DeletedCompanies int       `json:"deleted_companies"`
+	DeletedLocations int       `json:"deleted_locations"`
+	DeletedAt        time.Time `json:"deleted_at"`
+	AuditID          int64     `json:"audit_id"`
 }
 
 // UserProfile represents minimal user info from database
@@ -74,15 +97,373 @@ type Location struct {
 
 // AuditLog represents an audit log entry
 type AuditLog struct {
-	ID            string    `json:"id"`
-	Action        string    `json:"action"`
-	DeletedByEmail string   `json:"deleted_by_email"`
-	TargetEmail   string    `json:"target_email"`
-	TargetUserID  string    `json:"target_user_id"`
-	GroupIDs      []string  `json:"group_ids"`
-	CompanyIDs    []string  `json:"company_ids"`
-	LocationIDs   []string  `json:"location_ids"`
-	Reason        string    `json:"reason"`
-	IPAddress     string    `json:"ip_address"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID               string    `json:"id"`
+	Action           string    `json:"action"`
+	DeletedByEmail   string    `json:"deleted_by_email"`
+	ActorType        string    `json:"actor_type"`
+	TargetEmail      string    `json:"target_email"`
+	TargetUserID     string    `json:"target_user_id"`
+	GroupIDs         []string  `json:"group_ids"`
+	CompanyIDs       []string  `json:"company_ids"`
+	LocationIDs      []string  `json:"location_ids"`
+	Reason           string    `json:"reason"`
+	DeletedGroups    int       `json:"deleted_groups"`
+	DeletedCompanies int       `json:"deleted_companies"`
+	DeletedLocations int       `json:"deleted_locations"`
+	IPAddress        string    `json:"ip_address"`
+	UserAgent        string    `json:"user_agent"`
+	RequestID        string    `json:"request_id"`
+	SessionID        string    `json:"session_id"`
+	Query            string    `json:"query,omitempty"` // What was searched for, on access entries
+	CreatedAt        time.Time `json:"created_at"`
+	PrevHash         string    `json:"prev_hash"`
+	RowHash          string    `json:"row_hash"`
+
+	Entities []AuditEntity `json:"entities,omitempty"` // Only populated for a single entry
+}
+
+// AuditEntity is a user, group, company or location affected by an audited deletion
+type AuditEntity struct {
+	EntityType    string          `json:"entity_type"`
+	EntityID      string          `json:"entity_id"`
+	ParentID      string          `json:"parent_id"`
+	PreviousState json.RawMessage `json:"previous_state"`
+}
+
+// APIKey represents a service account API key (the secret itself is never stored)
+type APIKey struct {
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	CreatedByEmail     string     `json:"created_by_email"`
+	CreatedAt          time.Time  `json:"created_at"`
+	LastUsedAt         *time.Time `json:"last_used_at"`
+	RevokedAt          *time.Time `json:"revoked_at"`
+	RevokedByEmail     string     `json:"revoked_by_email,omitempty"`
+}
+
+// CreateAPIKeyRequest represents the request to create an API key
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	Scopes             []string `json:"scopes" binding:"required,min=1"`
+	RateLimitPerMinute int      `json:"rate_limit_per_minute" binding:"min=0"`
+	CreatedBy          string   `json:"-"` // Will be set by backend from JWT
+}
+
+// CreateAPIKeyResponse contains the new key; the plaintext secret is only returned once
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Audit log actions
+const (
+	AuditActionAccountDeletion = "ACCOUNT_DELETION"
+	AuditActionAccountLookup   = "ACCOUNT_LOOKUP"
+	AuditActionAuditLogView    = "AUDIT_LOG_VIEW"
+)
+
+// AccessActions are the audit actions recording reads of customer data
+var AccessActions = []string{AuditActionAccountLookup, AuditActionAuditLogView}
+
+// AccessEvent is a read of customer or audit data to be audited
+type AccessEvent struct {
+	Action       string
+	Actor        string
+	ActorType    string
+	Query        string
+	TargetEmail  string
+	TargetUserID string // User the read returned, if any
+	Metadata     RequestMetadata
+}
+
+// AccessSummary totals one actor's accesses to a customer's data
+type AccessSummary struct {
+	Actor       string    `json:"actor"`
+	ActorType   string    `json:"actor_type"`
+	Count       int       `json:"count"`
+	FirstAccess time.Time `json:"first_access"`
+	LastAccess  time.Time `json:"last_access"`
+}
+
+// AccessReport answers who looked at a customer's data
+type AccessReport struct {
+	TargetEmail  string          `json:"target_email,omitempty"`
+	TargetUserID string          `json:"target_user_id,omitempty"`
+	Total        int             `json:"total"`
+	Actors       []AccessSummary `json:"actors"`
+	Accesses     []AuditLog      `json:"accesses"` // Newest first, capped at 500
+}
+
+// AuditLogFilter narrows and pages an audit log search. Zero values are ignored.
+type AuditLogFilter struct {
+	Actor        string
+	TargetEmail  string
+	TargetUserID string
+	GroupID      string
+	Action       string
+	Reason       string // Case-insensitive substring match
+	From         *time.Time
+	To           *time.Time // Exclusive
+	Cursor       string     // Opaque cursor from a previous page's next_cursor
+	Limit        int
+}
+
+// AuditLogPage is one page of audit log search results
+type AuditLogPage struct {
+	Logs       []AuditLog `json:"logs"`
+	Total      int        `json:"total"`
+	Limit      int        `json:"limit"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// AuditChainReport is the result of verifying the audit log hash chain.
+// CheckedEntries and LastHash form the checkpoint to keep outside the
+// database.
+type AuditChainReport struct {
+	Valid          bool   `json:"valid"`
+	StartID        int64  `json:"start_id"` // First entry that must be hashed
+	CheckedEntries int64  `json:"checked_entries"`
+	LegacyEntries  int    `json:"legacy_entries"` // Entries written before hash chaining
+	FirstBrokenID  string `json:"first_broken_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	LastHash       string `json:"last_hash,omitempty"`
+}
+
+// AuditChainCheckpoint is a chain head recorded earlier, from the
+// checked_entries and last_hash of a report
+type AuditChainCheckpoint struct {
+	Count int64
+	Hash  string
+}
+
+// Audit export formats
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+)
+
+// AuditExportRequest describes an audit log export
+type AuditExportRequest struct {
+	ID          string
+	Format      string
+	Filter      AuditLogFilter
+	Query       string // Raw filter query string, recorded in the manifest
+	RequestedBy string
+}
+
+// ExportManifest describes an exported file and signs its hash
+type ExportManifest struct {
+	ID        string    `json:"id"`
+	Format    string    `json:"format"`
+	Filter    string    `json:"filter"`
+	RowCount  int       `json:"row_count"`
+	SHA256    string    `json:"sha256"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	Algorithm string    `json:"algorithm"`
+	KeyID     string    `json:"key_id"`
+	Signature string    `json:"signature"`
+}
+
+// ExportVerification is the result of checking a file against its manifest
+type ExportVerification struct {
+	ExportID       string `json:"export_id"`
+	Valid          bool   `json:"valid"`
+	SHA256         string `json:"sha256"`
+	HashMatches    bool   `json:"hash_matches"`
+	SignatureValid bool   `json:"signature_valid"`
+}
+
+// SigningKey describes the key used to sign exports
+type SigningKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key,omitempty"` // Empty for HMAC keys
+}
+
+// DeletionReceipt confirms to a customer what was erased and when. It is
+// derived from the deletion's audit entry, so it can be regenerated and
+// verified at any time.
+type DeletionReceipt struct {
+	Reference      string         `json:"reference"`
+	AuditID        string         `json:"audit_id"`
+	CustomerEmail  string         `json:"customer_email"`
+	CustomerID     string         `json:"customer_user_id"`
+	DeletedAt      time.Time      `json:"deleted_at"`
+	GeneratedAt    time.Time      `json:"generated_at"` // Not signed
+	Groups         []ReceiptGroup `json:"groups"`
+	TotalGroups    int            `json:"total_groups"`
+	TotalCompanies int            `json:"total_companies"`
+	TotalLocations int            `json:"total_locations"`
+	Algorithm      string         `json:"algorithm"`
+	KeyID          string         `json:"key_id"`
+	Signature      string         `json:"signature"`
+}
+
+// ReceiptGroup is one deleted business group and what it contained
+type ReceiptGroup struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Companies int    `json:"companies"`
+	Locations int    `json:"locations"`
+}
+
+// VerifyReceiptRequest is a public request to check a receipt
+type VerifyReceiptRequest struct {
+	Reference string `json:"reference" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// ReceiptVerification is the public result of checking a receipt. It only
+// repeats details already printed on a genuine receipt, with the email masked.
+type ReceiptVerification struct {
+	Valid          bool       `json:"valid"`
+	Reference      string     `json:"reference"`
+	CustomerEmail  string     `json:"customer_email,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	TotalGroups    int        `json:"total_groups,omitempty"`
+	TotalCompanies int        `json:"total_companies,omitempty"`
+	TotalLocations int        `json:"total_locations,omitempty"`
+}
+
+// Webhook event types
+const (
+	EventAccountLookup            = "account.lookup"
+	EventAccountDeletionRequested = "account.deletion.requested"
+	EventAccountDeletionExecuted  = "account.deletion.executed"
+	EventAccountDeletionFailed    = "account.deletion.failed"
+	EventWebhookPing              = "webhook.ping"
+)
+
+// EventTypes are the event types webhooks can subscribe to
+var EventTypes = []string{
+	EventAccountLookup,
+	EventAccountDeletionRequested,
+	EventAccountDeletionExecuted,
+	EventAccountDeletionFailed,
+}
+
+// Event is a deletion lifecycle event sent to downstream systems
+type Event struct {
+	ID         string      `json:"id"` // Stable across retries, for deduplication
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Actor      string      `json:"actor"`
+	Data       interface{} `json:"data"`
+}
+
+// LookupEventData is the data of an account.lookup event
+type LookupEventData struct {
+	Email  string `json:"email"`
+	UserID string `json:"user_id,omitempty"`
+	Found  bool   `json:"found"`
+}
+
+// DeletionEventData is the data of account.deletion.* events
+type DeletionEventData struct {
+	UserID           string     `json:"user_id"`
+	Email            string     `json:"email"`
+	GroupIDs         []string   `json:"group_ids"`
+	Reason           string     `json:"reason"`
+	AuditID          int64      `json:"audit_id,omitempty"`
+	DeletedGroups    int        `json:"deleted_groups,omitempty"`
+	DeletedCompanies int        `json:"deleted_companies,omitempty"`
+	DeletedLocations int        `json:"deleted_locations,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	ErrorCode        string     `json:"error_code,omitempty"` // Service error code of a failed deletion
+	Error            string     `json:"error,omitempty"`      // Message safe to show outside the service
+}
+
+// WebhookSubscription is a downstream endpoint subscribed to events
+type WebhookSubscription struct {
+	ID             int64      `json:"id"`
+	URL            string     `json:"url"`
+	EventTypes     []string   `json:"event_types"`
+	Description    string     `json:"description"`
+	CreatedByEmail string     `json:"created_by_email"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+}
+
+// CreateWebhookRequest represents the request to create a webhook subscription
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	Description string   `json:"description" binding:"max=255"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"` // Generated when empty
+	CreatedBy   string   `json:"-"`                                         // Will be set by backend from JWT
+}
+
+// CreateWebhookResponse contains the new subscription; the secret is only returned once
+type CreateWebhookResponse struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one attempt record of sending an event to a subscription
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+// WebhookDeadLetter is a delivery that exhausted its retries
+type WebhookDeadLetter struct {
+	ID             int64           `json:"id"`
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// ErasureRequest is what a saga participant needs to erase a deleted account
+type ErasureRequest struct {
+	SagaID   int64    `json:"saga_id"`
+	AuditID  int64    `json:"audit_id"`
+	UserID   string   `json:"user_id"`
+	Email    string   `json:"email"`
+	GroupIDs []string `json:"group_ids"`
+}
+
+// ErasureSaga tracks erasure of a deleted account in external services
+type ErasureSaga struct {
+	ID          int64         `json:"id"`
+	AuditID     int64         `json:"audit_id"`
+	UserID      string        `json:"user_id"`
+	Email       string        `json:"email"`
+	GroupIDs    []string      `json:"group_ids"`
+	Status      string        `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	CompletedAt *time.Time    `json:"completed_at"`
+	Steps       []ErasureStep `json:"steps,omitempty"`
+}
+
+// ErasureStep is one participant's progress in an erasure saga
+type ErasureStep struct {
+	Participant          string     `json:"participant"`
+	Position             int        `json:"position"`
+	Status               string     `json:"status"`
+	Attempts             int        `json:"attempts"`
+	CompensationAttempts int        `json:"compensation_attempts"`
+	LastError            string     `json:"last_error,omitempty"`
+	ConfirmedAt          *time.Time `json:"confirmed_at"`
+	CompensatedAt        *time.Time `json:"compensated_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
 }
@@ -3,11 +3,18 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/metrics"
 	"go.appointy.com/admin-deletion-dashboard/internal/models"
-	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AccountService handles account operations
@@ -24,16 +31,48 @@ func NewAccountService(db *sql.DB) *AccountService {
 
 // LookupAccount finds a user and their owned groups/companies/locations
 func (s *AccountService) LookupAccount(ctx context.Context, email string) (*models.AccountLookupResponse, error) {
+	ctx, span := tracer.Start(ctx, "AccountService.LookupAccount")
+	account, err := s.lookupAccount(ctx, email)
+	if err == nil {
+		companies, locations := 0, 0
+		for _, group := range account.Groups {
+			companies += group.CompanyCount
+			locations += group.LocationCount
+		}
+		span.SetAttributes(
+			attribute.Int("account.groups", len(account.Groups)),
+			attribute.Int("account.companies", companies),
+			attribute.Int("account.locations", locations),
+		)
+	}
+	endSpan(span, err)
+
+	switch {
+	case err == nil:
+		metrics.Lookups.WithLabelValues("found").Inc()
+		slog.InfoContext(ctx, "account looked up", logging.TargetUserID(account.UserID), slog.Int("groups", len(account.Groups)))
+	case errors.Is(err, ErrAccountNotFound):
+		metrics.Lookups.WithLabelValues("not_found").Inc()
+		slog.InfoContext(ctx, "account not found")
+	default:
+		metrics.Lookups.WithLabelValues("error").Inc()
+		slog.ErrorContext(ctx, "account lookup failed", logging.Err(err))
+	}
+	return account, err
+}
+
+// lookupAccount finds an account and counts the hierarchy under each group
+func (s *AccountService) lookupAccount(ctx context.Context, email string) (*models.AccountLookupResponse, error) {
 	// Step 1: Find user profile
 	user, err := s.getUserByEmail(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find user: %w", err)
+		return nil, databaseError("find user", err)
 	}
 
 	// Step 2: Find groups owned by this user
 	groups, err := s.getGroupsByOwner(ctx, user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find groups: %w", err)
+		return nil, databaseError("find groups", err)
 	}
 
 	// Step 3: For each group, count companies and locations
@@ -41,7 +80,7 @@ func (s *AccountService) LookupAccount(ctx context.Context, email string) (*mode
 	for _, group := range groups {
 		companyCount, locationCount, err := s.getHierarchyCounts(ctx, group.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get hierarchy counts for group %s: %w", group.ID, err)
+			return nil, databaseError(fmt.Sprintf("get hierarchy counts for group %s", group.ID), err)
 		}
 
 		groupInfos = append(groupInfos, models.GroupInfo{
@@ -62,69 +101,182 @@ func (s *AccountService) LookupAccount(ctx context.Context, email string) (*mode
 }
 
 // DeleteAccount performs soft delete on user and selected groups hierarchy
-func (s *AccountService) DeleteAccount(ctx context.Context, req *models.DeleteAccountRequest) (*models.DeleteAccountResponse, error) {
+func (s *AccountService) DeleteAccount(ctx context.Context, req *models.DeleteAccountRequest) (_ *models.DeleteAccountResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.DeleteAccount", trace.WithAttributes(
+		attribute.Int("deletion.groups_selected", len(req.GroupIDs)),
+		attribute.String("deletion.actor_type", req.ActorType),
+	))
+	defer func() { endSpan(span, err) }()
+	ctx = logging.With(ctx, logging.TargetUserID(req.UserID))
+
+	if err := validateDeletion(req); err != nil {
+		metrics.Deletions.WithLabelValues(KindOf(err).String(), req.ActorType).Inc()
+		return nil, err
+	}
+
+	// Announce the request even if the deletion later fails
+	err = enqueueEvent(ctx, s.db, &models.Event{
+		Type:  models.EventAccountDeletionRequested,
+		Actor: req.DeletedBy,
+		Data: models.DeletionEventData{
+			UserID:   req.UserID,
+			Email:    req.Email,
+			GroupIDs: req.GroupIDs,
+			Reason:   req.Reason,
+		},
+	})
+	if err != nil {
+		metrics.Deletions.WithLabelValues(KindOf(err).String(), req.ActorType).Inc()
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := s.deleteAccount(ctx, req)
+	if err != nil {
+		metrics.DeletionDuration.WithLabelValues("rolled_back").Observe(time.Since(start).Seconds())
+		metrics.Deletions.WithLabelValues(KindOf(err).String(), req.ActorType).Inc()
+
+		// The transaction rolled back; announce the failure on its own, even
+		// if the request was cancelled. Subscribers get only the public code
+		// and message, as API callers do.
+		errorCode, errorMessage := PublicError(err)
+		failErr := enqueueEvent(context.WithoutCancel(ctx), s.db, &models.Event{
+			Type:  models.EventAccountDeletionFailed,
+			Actor: req.DeletedBy,
+			Data: models.DeletionEventData{
+				UserID:    req.UserID,
+				Email:     req.Email,
+				GroupIDs:  req.GroupIDs,
+				Reason:    req.Reason,
+				ErrorCode: errorCode,
+				Error:     errorMessage,
+			},
+		})
+		if failErr != nil {
+			slog.ErrorContext(ctx, "failed to record deletion failure event", logging.Err(failErr))
+		}
+
+		level := slog.LevelWarn
+		if kind := KindOf(err); kind == KindInternal || kind == KindUnavailable {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "account deletion rolled back",
+			slog.String("error_kind", KindOf(err).String()),
+			logging.Err(err),
+			logging.Duration(time.Since(start)),
+		)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int64("deletion.audit_id", result.AuditID),
+		attribute.Int("deletion.groups", result.DeletedGroups),
+		attribute.Int("deletion.companies", result.DeletedCompanies),
+		attribute.Int("deletion.locations", result.DeletedLocations),
+	)
+
+	metrics.DeletionDuration.WithLabelValues("committed").Observe(time.Since(start).Seconds())
+	metrics.Deletions.WithLabelValues("success", req.ActorType).Inc()
+	metrics.EntitiesDeleted.WithLabelValues(models.EntityTypeUser).Inc()
+	metrics.EntitiesDeleted.WithLabelValues(models.EntityTypeGroup).Add(float64(result.DeletedGroups))
+	metrics.EntitiesDeleted.WithLabelValues(models.EntityTypeCompany).Add(float64(result.DeletedCompanies))
+	metrics.EntitiesDeleted.WithLabelValues(models.EntityTypeLocation).Add(float64(result.DeletedLocations))
+
+	slog.InfoContext(ctx, "account deleted",
+		logging.AuditID(result.AuditID),
+		slog.Int("groups", result.DeletedGroups),
+		slog.Int("companies", result.DeletedCompanies),
+		slog.Int("locations", result.DeletedLocations),
+		logging.Duration(time.Since(start)),
+	)
+	return result, nil
+}
+
+// deleteAccount soft deletes the selected hierarchy, audits it and writes the
+// executed event in one transaction
+func (s *AccountService) deleteAccount(ctx context.Context, req *models.DeleteAccountRequest) (*models.DeleteAccountResponse, error) {
 	// Start transaction
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to start transaction: %w", err)
+		return nil, databaseError("start transaction", err)
 	}
 	defer tx.Rollback()
 
-	now := time.Now()
+	if err := s.lockDeletionTargets(ctx, tx, req); err != nil {
+		return nil, err
+	}
+
+	// Postgres stores microseconds; truncate so audit hashes match what is read back
+	now := time.Now().Truncate(time.Microsecond)
 	deletedGroups := 0
 	deletedCompanies := 0
 	deletedLocations := 0
+	entities := make([]models.AuditEntity, 0)
 
 	// For each selected group
 	for _, groupID := range req.GroupIDs {
-		// Get all companies under this group
-		companies, err := s.getCompaniesByParent(ctx, groupID)
+		groupEntities, companies, locations, err := s.deleteGroupHierarchy(ctx, tx, req, groupID, now)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get companies for group %s: %w", groupID, err)
-		}
-
-		// For each company, get and delete locations
-		for _, company := range companies {
-			locations, err := s.getLocationsByParent(ctx, company.ID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get locations for company %s: %w", company.ID, err)
-			}
-
-			// Soft delete locations
-			for _, location := range locations {
-				if err := s.softDeleteLocation(ctx, tx, location.ID, req.DeletedBy, now); err != nil {
-					return nil, fmt.Errorf("failed to delete location %s: %w", location.ID, err)
-				}
-				deletedLocations++
-			}
-
-			// Soft delete company
-			if err := s.softDeleteCompany(ctx, tx, company.ID, req.DeletedBy, now); err != nil {
-				return nil, fmt.Errorf("failed to delete company %s: %w", company.ID, err)
-			}
-			deletedCompanies++
-		}
-
-		// Soft delete group
-		if err := s.softDeleteGroup(ctx, tx, groupID, req.DeletedBy, now); err != nil {
-			return nil, fmt.Errorf("failed to delete group %s: %w", groupID, err)
+			return nil, err
 		}
+		entities = append(entities, groupEntities...)
+		deletedCompanies += companies
+		deletedLocations += locations
 		deletedGroups++
 	}
 
 	// Soft delete user profile
-	if err := s.softDeleteUser(ctx, tx, req.UserID, req.DeletedBy, now); err != nil {
-		return nil, fmt.Errorf("failed to delete user: %w", err)
+	previous, err := s.softDeleteUser(ctx, tx, req.UserID, req.DeletedBy, now)
+	if err != nil {
+		return nil, databaseError("delete user", err)
+	}
+	entities = appendAuditEntity(entities, models.EntityTypeUser, req.UserID, "", previous)
+
+	// Create audit log with the exact entities affected
+	auditID, err := s.createAuditLog(ctx, tx, &auditEntry{
+		Action:           models.AuditActionAccountDeletion,
+		Actor:            req.DeletedBy,
+		ActorType:        req.ActorType,
+		TargetEmail:      req.Email,
+		TargetUserID:     req.UserID,
+		GroupIDs:         req.GroupIDs,
+		Reason:           req.Reason,
+		DeletedGroups:    deletedGroups,
+		DeletedCompanies: deletedCompanies,
+		DeletedLocations: deletedLocations,
+		Metadata:         req.Metadata,
+		Entities:         entities,
+		CreatedAt:        now,
+	})
+	if err != nil {
+		return nil, databaseError("create audit log", err)
 	}
 
-	// Create audit log
-	if err := s.createAuditLog(ctx, tx, req, deletedGroups, deletedCompanies, deletedLocations, now); err != nil {
-		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	// Write the event in the same transaction so it is published if and only
+	// if the deletion commits
+	err = enqueueEvent(ctx, tx, &models.Event{
+		Type:       models.EventAccountDeletionExecuted,
+		OccurredAt: now.UTC(),
+		Actor:      req.DeletedBy,
+		Data: models.DeletionEventData{
+			UserID:           req.UserID,
+			Email:            req.Email,
+			GroupIDs:         req.GroupIDs,
+			Reason:           req.Reason,
+			AuditID:          auditID,
+			DeletedGroups:    deletedGroups,
+			DeletedCompanies: deletedCompanies,
+			DeletedLocations: deletedLocations,
+			DeletedAt:        &now,
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, databaseError("commit transaction", err)
 	}
 
 	return &models.DeleteAccountResponse{
@@ -134,9 +286,136 @@ func (s *AccountService) DeleteAccount(ctx context.Context, req *models.DeleteAc
 		DeletedCompanies: deletedCompanies,
 		DeletedLocations: deletedLocations,
 		DeletedAt:        now,
+		AuditID:          auditID,
 	}, nil
 }
 
+// deleteGroupHierarchy soft deletes a group with its companies and their
+// locations, returning the audited entities and the companies and locations
+// deleted
+func (s *AccountService) deleteGroupHierarchy(ctx context.Context, tx *sql.Tx, req *models.DeleteAccountRequest, groupID string, now time.Time) (_ []models.AuditEntity, deletedCompanies, deletedLocations int, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.deleteGroupHierarchy")
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("deletion.companies", deletedCompanies),
+			attribute.Int("deletion.locations", deletedLocations),
+		)
+		endSpan(span, err)
+	}()
+
+	entities := make([]models.AuditEntity, 0)
+
+	// Get all companies under this group
+	companies, err := s.getCompaniesByParent(ctx, groupID)
+	if err != nil {
+		return nil, 0, 0, databaseError(fmt.Sprintf("get companies for group %s", groupID), err)
+	}
+
+	// For each company, get and delete locations
+	for _, company := range companies {
+		locations, err := s.getLocationsByParent(ctx, company.ID)
+		if err != nil {
+			return nil, 0, 0, databaseError(fmt.Sprintf("get locations for company %s", company.ID), err)
+		}
+
+		// Soft delete locations
+		for _, location := range locations {
+			previous, err := s.softDeleteLocation(ctx, tx, location.ID, req.DeletedBy, now)
+			if err != nil {
+				return nil, 0, 0, databaseError(fmt.Sprintf("delete location %s", location.ID), err)
+			}
+			entities = appendAuditEntity(entities, models.EntityTypeLocation, location.ID, company.ID, previous)
+			deletedLocations++
+		}
+
+		// Soft delete company
+		previous, err := s.softDeleteCompany(ctx, tx, company.ID, req.DeletedBy, now)
+		if err != nil {
+			return nil, 0, 0, databaseError(fmt.Sprintf("delete company %s", company.ID), err)
+		}
+		entities = appendAuditEntity(entities, models.EntityTypeCompany, company.ID, groupID, previous)
+		deletedCompanies++
+	}
+
+	// Soft delete group
+	previous, err := s.softDeleteGroup(ctx, tx, groupID, req.DeletedBy, now)
+	if err != nil {
+		return nil, 0, 0, databaseError(fmt.Sprintf("delete group %s", groupID), err)
+	}
+	entities = appendAuditEntity(entities, models.EntityTypeGroup, groupID, req.UserID, previous)
+
+	return entities, deletedCompanies, deletedLocations, nil
+}
+
+// validateDeletion checks a deletion request before anything is recorded
+func validateDeletion(req *models.DeleteAccountRequest) error {
+	if strings.TrimSpace(req.Email) == "" || strings.TrimSpace(req.UserID) == "" {
+		return InvalidInput("email and user_id are required")
+	}
+	if len(req.GroupIDs) == 0 {
+		return ErrNoGroupsSelected
+	}
+
+	seen := make(map[string]bool, len(req.GroupIDs))
+	for _, groupID := range req.GroupIDs {
+		if seen[groupID] {
+			return InvalidInput("group " + groupID + " is selected more than once")
+		}
+		seen[groupID] = true
+	}
+	return nil
+}
+
+// lockDeletionTargets locks the user and the selected groups and checks they
+// can still be deleted as requested, since the lookup the request was built
+// from may be stale
+func (s *AccountService) lockDeletionTargets(ctx context.Context, tx *sql.Tx, req *models.DeleteAccountRequest) error {
+	var email string
+	var deleted bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT email, COALESCE(is_deleted, false)
+		FROM saastack_user_v1.user_profile
+		WHERE id = $1
+		FOR UPDATE
+	`, req.UserID).Scan(&email, &deleted)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound.withMessage("account %s not found", req.UserID)
+	}
+	if err != nil {
+		return databaseError("lock user", err)
+	}
+	if !strings.EqualFold(email, req.Email) {
+		return ErrAccountMismatch
+	}
+	if deleted {
+		return ErrAccountAlreadyDeleted.withMessage("account %s is already deleted", req.Email)
+	}
+
+	for _, groupID := range req.GroupIDs {
+		var owner string
+		err := tx.QueryRowContext(ctx, `
+			SELECT created_by, COALESCE(is_deleted, false)
+			FROM saastack_group_v1.groups
+			WHERE id = $1
+			FOR UPDATE
+		`, groupID).Scan(&owner, &deleted)
+		if err == sql.ErrNoRows {
+			return ErrGroupNotFound.withMessage("group %s not found", groupID)
+		}
+		if err != nil {
+			return databaseError(fmt.Sprintf("lock group %s", groupID), err)
+		}
+		if owner != req.UserID {
+			return ErrGroupNotOwned.withMessage("group %s is not owned by %s", groupID, req.Email)
+		}
+		if deleted {
+			return ErrGroupAlreadyDeleted.withMessage("group %s is already deleted", groupID)
+		}
+	}
+
+	return nil
+}
+
 // getUserByEmail retrieves user profile by email
 func (s *AccountService) getUserByEmail(ctx context.Context, email string) (*models.UserProfile, error) {
 	query := `
@@ -155,7 +434,7 @@ func (s *AccountService) getUserByEmail(ctx context.Context, email string) (*mod
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found with email: %s", email)
+		return nil, ErrAccountNotFound.withMessage("no active account found for %s", email)
 	}
 	if err != nil {
 		return nil, err
@@ -273,109 +552,84 @@ func (s *AccountService) getHierarchyCounts(ctx context.Context, groupID string)
 	return companyCount, locationCount, nil
 }
 
-// softDeleteLocation marks a location as deleted
-func (s *AccountService) softDeleteLocation(ctx context.Context, tx *sql.Tx, locationID, deletedBy string, deletedOn time.Time) error {
+// softDeleteLocation marks a location as deleted and returns its previous state
+func (s *AccountService) softDeleteLocation(ctx context.Context, tx *sql.Tx, locationID, deletedBy string, deletedOn time.Time) (json.RawMessage, error) {
 	query := `
-		UPDATE saastack_location_v1.location
+		UPDATE saastack_location_v1.location AS t
 		SET is_deleted = true, deleted_by = $1, deleted_on = $2
-		WHERE id = $3
+		FROM (
+			SELECT id, name, parent, is_deleted, deleted_by, deleted_on
+			FROM saastack_location_v1.location
+			WHERE id = $3
+			FOR UPDATE
+		) AS old
+		WHERE t.id = old.id
+		RETURNING json_build_object('name', old.name, 'parent', old.parent, 'is_deleted', old.is_deleted, 'deleted_by', old.deleted_by, 'deleted_on', old.deleted_on)
 	`
-	_, err := tx.ExecContext(ctx, query, deletedBy, deletedOn, locationID)
-	return err
+	return s.softDelete(ctx, tx, query, deletedBy, deletedOn, locationID)
 }
 
-// softDeleteCompany marks a company as deleted
-func (s *AccountService) softDeleteCompany(ctx context.Context, tx *sql.Tx, companyID, deletedBy string, deletedOn time.Time) error {
+// softDeleteCompany marks a company as deleted and returns its previous state
+func (s *AccountService) softDeleteCompany(ctx context.Context, tx *sql.Tx, companyID, deletedBy string, deletedOn time.Time) (json.RawMessage, error) {
 	query := `
-		UPDATE saastack_company_v1.company
+		UPDATE saastack_company_v1.company AS t
 		SET is_deleted = true, deleted_by = $1, deleted_on = $2
-		WHERE id = $3
+		FROM (
+			SELECT id, name, parent, is_deleted, deleted_by, deleted_on
+			FROM saastack_company_v1.company
+			WHERE id = $3
+			FOR UPDATE
+		) AS old
+		WHERE t.id = old.id
+		RETURNING json_build_object('name', old.name, 'parent', old.parent, 'is_deleted', old.is_deleted, 'deleted_by', old.deleted_by, 'deleted_on', old.deleted_on)
 	`
-	_, err := tx.ExecContext(ctx, query, deletedBy, deletedOn, companyID)
-	return err
+	return s.softDelete(ctx, tx, query, deletedBy, deletedOn, companyID)
 }
 
-// softDeleteGroup marks a group as deleted
-func (s *AccountService) softDeleteGroup(ctx context.Context, tx *sql.Tx, groupID, deletedBy string, deletedOn time.Time) error {
+// softDeleteGroup marks a group as deleted and returns its previous state
+func (s *AccountService) softDeleteGroup(ctx context.Context, tx *sql.Tx, groupID, deletedBy string, deletedOn time.Time) (json.RawMessage, error) {
 	query := `
-		UPDATE saastack_group_v1.groups
+		UPDATE saastack_group_v1.groups AS t
 		SET is_deleted = true, deleted_by = $1, deleted_on = $2
-		WHERE id = $3
+		FROM (
+			SELECT id, name, parent, is_deleted, deleted_by, deleted_on
+			FROM saastack_group_v1.groups
+			WHERE id = $3
+			FOR UPDATE
+		) AS old
+		WHERE t.id = old.id
+		RETURNING json_build_object('name', old.name, 'parent', old.parent, 'is_deleted', old.is_deleted, 'deleted_by', old.deleted_by, 'deleted_on', old.deleted_on)
 	`
-	_, err := tx.ExecContext(ctx, query, deletedBy, deletedOn, groupID)
-	return err
+	return s.softDelete(ctx, tx, query, deletedBy, deletedOn, groupID)
 }
 
-// softDeleteUser marks a user profile as deleted
-func (s *AccountService) softDeleteUser(ctx context.Context, tx *sql.Tx, userID, deletedBy string, deletedOn time.Time) error {
+// softDeleteUser marks an user profile as deleted and returns its previous state
+func (s *AccountService) softDeleteUser(ctx context.Context, tx *sql.Tx, userID, deletedBy string, deletedOn time.Time) (json.RawMessage, error) {
 	query := `
-		UPDATE saastack_user_v1.user_profile
+		UPDATE saastack_user_v1.user_profile AS t
 		SET is_deleted = true, deleted_by = $1, deleted_on = $2
-		WHERE id = $3
+		FROM (
+			SELECT id, email, is_deleted, deleted_by, deleted_on
+			FROM saastack_user_v1.user_profile
+			WHERE id = $3
+			FOR UPDATE
+		) AS old
+		WHERE t.id = old.id
+		RETURNING json_build_object('email', old.email, 'is_deleted', old.is_deleted, 'deleted_by', old.deleted_by, 'deleted_on', old.deleted_on)
 	`
-	_, err := tx.ExecContext(ctx, query, deletedBy, deletedOn, userID)
-	return err
-}
-
-// createAuditLog creates an audit log entry
-func (s *AccountService) createAuditLog(ctx context.Context, tx *sql.Tx, req *models.DeleteAccountRequest, deletedGroups, deletedCompanies, deletedLocations int, timestamp time.Time) error {
-	query := `
-		INSERT INTO admin_deletion_audit_log
-		(action, deleted_by_email, target_email, target_user_id, group_ids, reason, deleted_groups, deleted_companies, deleted_locations, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`
-
-	_, err := tx.ExecContext(ctx, query,
-		"ACCOUNT_DELETION",
-		req.DeletedBy,
-		req.Email,
-		req.UserID,
-		pq.Array(req.GroupIDs),
-		req.Reason,
-		deletedGroups,
-		deletedCompanies,
-		deletedLocations,
-		timestamp,
-	)
-
-	return err
+	return s.softDelete(ctx, tx, query, deletedBy, deletedOn, userID)
 }
 
-// GetAuditLogs retrieves audit logs with optional filtering
-func (s *AccountService) GetAuditLogs(ctx context.Context, limit int, offset int) ([]models.AuditLog, error) {
-	query := `
-		SELECT action, deleted_by_email, target_email, target_user_id, group_ids, reason, created_at
-		FROM admin_deletion_audit_log
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+// softDelete runs a soft delete query returning the row's previous state.
+// A missing row is not an error; it yields a nil state and is not audited.
+func (s *AccountService) softDelete(ctx context.Context, tx *sql.Tx, query, deletedBy string, deletedOn time.Time, id string) (json.RawMessage, error) {
+	var previous []byte
+	err := tx.QueryRowContext(ctx, query, deletedBy, deletedOn, id).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	logs := make([]models.AuditLog, 0)
-	for rows.Next() {
-		var log models.AuditLog
-		var groupIDs pq.StringArray
-
-		if err := rows.Scan(
-			&log.Action,
-			&log.DeletedByEmail,
-			&log.TargetEmail,
-			&log.TargetUserID,
-			&groupIDs,
-			&log.Reason,
-			&log.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-
-		log.GroupIDs = []string(groupIDs)
-		logs = append(logs, log)
-	}
-
-	return logs, rows.Err()
+	return json.RawMessage(previous), nil
 }
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+const (
+	apiKeyPrefix       = "adk_"
+	apiKeyDisplayChars = 12
+)
+
+var (
+	// ErrAPIKeyInvalid is returned for unknown or revoked API keys
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+
+	// ErrAPIKeyNotFound is returned when revoking an unknown or already revoked key
+	ErrAPIKeyNotFound = newError(KindNotFound, "api_key_not_found", "api key not found")
+)
+
+// APIKeyService manages service account API keys
+type APIKeyService struct {
+	db *sql.DB
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{
+		db: db,
+	}
+}
+
+// CreateAPIKey generates a new key and stores its hash
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	query := `
+		INSERT INTO admin_api_keys
+		(name, key_prefix, key_hash, scopes, rate_limit_per_minute, created_by_email)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	resp := &models.CreateAPIKeyResponse{
+		APIKey: models.APIKey{
+			Name:               req.Name,
+			Prefix:             key[:apiKeyDisplayChars],
+			Scopes:             req.Scopes,
+			RateLimitPerMinute: req.RateLimitPerMinute,
+			CreatedByEmail:     req.CreatedBy,
+		},
+		Key: key,
+	}
+
+	err = s.db.QueryRowContext(ctx, query,
+		req.Name,
+		resp.Prefix,
+		hashAPIKey(key),
+		pq.Array(req.Scopes),
+		req.RateLimitPerMinute,
+		req.CreatedBy,
+	).Scan(&resp.ID, &resp.CreatedAt)
+	if err != nil {
+		return nil, databaseError("create api key", err)
+	}
+
+	return resp, nil
+}
+
+// ListAPIKeys returns all keys, including revoked ones
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, rate_limit_per_minute, created_by_email,
+			created_at, last_used_at, revoked_at, revoked_by_email
+		FROM admin_api_keys
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, databaseError("list api keys", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		var key models.APIKey
+		var scopes pq.StringArray
+		var lastUsedAt, revokedAt sql.NullTime
+		var revokedBy sql.NullString
+
+		if err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&scopes,
+			&key.RateLimitPerMinute,
+			&key.CreatedByEmail,
+			&key.CreatedAt,
+			&lastUsedAt,
+			&revokedAt,
+			&revokedBy,
+		); err != nil {
+			return nil, databaseError("list api keys", err)
+		}
+
+		key.Scopes = []string(scopes)
+		key.LastUsedAt = nullTimePtr(lastUsedAt)
+		key.RevokedAt = nullTimePtr(revokedAt)
+		key.RevokedByEmail = revokedBy.String
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("list api keys", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key so it can no longer authenticate
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int64, revokedBy string) error {
+	query := `
+		UPDATE admin_api_keys
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_by_email = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := s.db.ExecContext(ctx, query, revokedBy, id)
+	if err != nil {
+		return databaseError("revoke api key", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return databaseError("revoke api key", err)
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey looks up an active key by its hash and records its use
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	query := `
+		SELECT id, name, key_prefix, scopes, rate_limit_per_minute, created_by_email, created_at
+		FROM admin_api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	var apiKey models.APIKey
+	var scopes pq.StringArray
+	err := s.db.QueryRowContext(ctx, query, hashAPIKey(key)).Scan(
+		&apiKey.ID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&scopes,
+		&apiKey.RateLimitPerMinute,
+		&apiKey.CreatedByEmail,
+		&apiKey.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, databaseError("look up api key", err)
+	}
+	apiKey.Scopes = []string(scopes)
+
+	// Only write last_used_at once a minute to keep hot keys cheap
+	touch := `
+		UPDATE admin_api_keys
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`
+	if _, err := s.db.ExecContext(ctx, touch, apiKey.ID); err != nil {
+		return nil, databaseError("record api key use", err)
+	}
+
+	return &apiKey, nil
+}
+
+// generateAPIKey returns a new random key with the recognizable prefix
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys are 256-bit random
+// values, so a fast hash is sufficient.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// nullTimePtr converts a sql.NullTime to a *time.Time
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
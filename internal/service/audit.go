@@ -0,0 +1,557 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrAuditLogNotFound is returned when an audit log entry does not exist
+	ErrAuditLogNotFound = newError(KindNotFound, "audit_log_not_found", "audit log not found")
+
+	// ErrInvalidCursor is returned for a malformed pagination cursor
+	ErrInvalidCursor = newError(KindValidation, "invalid_cursor", "invalid cursor")
+
+	// ErrInvalidFilter is returned for malformed audit log search parameters
+	ErrInvalidFilter = newError(KindValidation, "invalid_filter", "invalid audit log filter")
+)
+
+// accessReportLimit caps the individual accesses listed in an access report
+const accessReportLimit = 500
+
+// auditLogColumns are the columns read into models.AuditLog by scanAuditLog
+const auditLogColumns = `id, action, deleted_by_email, actor_type, target_email, target_user_id, group_ids, reason,
+	deleted_groups, deleted_companies, deleted_locations, ip_address, user_agent, request_id, session_id, query, created_at,
+	COALESCE(prev_hash::text, ''), COALESCE(row_hash::text, '')`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// auditEntry is a new audit log entry
+type auditEntry struct {
+	Action           string
+	Actor            string
+	ActorType        string
+	TargetEmail      string
+	TargetUserID     string
+	GroupIDs         []string
+	Reason           string
+	Query            string
+	DeletedGroups    int
+	DeletedCompanies int
+	DeletedLocations int
+	Metadata         models.RequestMetadata
+	Entities         []models.AuditEntity
+	CreatedAt        time.Time
+}
+
+// createAuditLog creates an audit log entry with its affected entities and
+// links it into the hash chain. The chain lock is held until the transaction
+// ends so entries are chained in commit order, even across instances.
+func (s *AccountService) createAuditLog(ctx context.Context, tx *sql.Tx, entry *auditEntry) (int64, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLockID); err != nil {
+		return 0, databaseError("acquire audit chain lock", err)
+	}
+
+	query := `
+		INSERT INTO admin_deletion_audit_log
+		(action, deleted_by_email, actor_type, target_email, target_user_id, group_ids, reason, query, deleted_groups, deleted_companies,
+		deleted_locations, ip_address, user_agent, request_id, session_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id
+	`
+
+	actorType := entry.ActorType
+	if actorType == "" {
+		actorType = "user"
+	}
+
+	groupIDs := entry.GroupIDs
+	if groupIDs == nil {
+		groupIDs = []string{}
+	}
+
+	var auditID int64
+	err := tx.QueryRowContext(ctx, query,
+		entry.Action,
+		entry.Actor,
+		actorType,
+		entry.TargetEmail,
+		entry.TargetUserID,
+		pq.Array(groupIDs),
+		entry.Reason,
+		entry.Query,
+		entry.DeletedGroups,
+		entry.DeletedCompanies,
+		entry.DeletedLocations,
+		entry.Metadata.IPAddress,
+		entry.Metadata.UserAgent,
+		entry.Metadata.RequestID,
+		entry.Metadata.SessionID,
+		entry.CreatedAt,
+	).Scan(&auditID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.createAuditEntities(ctx, tx, auditID, entry.Entities); err != nil {
+		return 0, databaseError("record audit entities", err)
+	}
+
+	if err := s.sealAuditLog(ctx, tx, auditID); err != nil {
+		return 0, databaseError("chain audit log", err)
+	}
+
+	return auditID, nil
+}
+
+// RecordAccess records a read of customer or audit data, such as an account
+// lookup or an audit log view, in the audit log
+func (s *AccountService) RecordAccess(ctx context.Context, event *models.AccessEvent) (err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.RecordAccess")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return databaseError("start transaction", err)
+	}
+	defer tx.Rollback()
+
+	_, err = s.createAuditLog(ctx, tx, &auditEntry{
+		Action:       event.Action,
+		Actor:        event.Actor,
+		ActorType:    event.ActorType,
+		TargetEmail:  event.TargetEmail,
+		TargetUserID: event.TargetUserID,
+		Query:        event.Query,
+		Metadata:     event.Metadata,
+		CreatedAt:    time.Now().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return databaseError("record access", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetAccessReport lists who looked up or viewed audit data about a customer,
+// matched by email and/or user ID
+func (s *AccountService) GetAccessReport(ctx context.Context, email, userID string) (_ *models.AccessReport, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.GetAccessReport")
+	defer func() { endSpan(span, err) }()
+
+	args := []interface{}{pq.Array(models.AccessActions), email, userID}
+	where := `
+		WHERE action = ANY($1)
+		AND (($2 <> '' AND LOWER(target_email) = LOWER($2)) OR ($3 <> '' AND target_user_id = $3))`
+
+	report := &models.AccessReport{
+		TargetEmail:  email,
+		TargetUserID: userID,
+		Actors:       make([]models.AccessSummary, 0),
+		Accesses:     make([]models.AuditLog, 0),
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT deleted_by_email, actor_type, COUNT(*), MIN(created_at), MAX(created_at)
+		FROM admin_deletion_audit_log`+where+`
+		GROUP BY deleted_by_email, actor_type
+		ORDER BY MAX(created_at) DESC
+	`, args...)
+	if err != nil {
+		return nil, databaseError("summarize access", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary models.AccessSummary
+		if err := rows.Scan(&summary.Actor, &summary.ActorType, &summary.Count, &summary.FirstAccess, &summary.LastAccess); err != nil {
+			return nil, databaseError("summarize access", err)
+		}
+		report.Total += summary.Count
+		report.Actors = append(report.Actors, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("summarize access", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT `+auditLogColumns+`
+		FROM admin_deletion_audit_log`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+strconv.Itoa(accessReportLimit), args...)
+	if err != nil {
+		return nil, databaseError("get accesses", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, databaseError("get accesses", err)
+		}
+		report.Accesses = append(report.Accesses, *log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("get accesses", err)
+	}
+
+	return report, nil
+}
+
+// createAuditEntities records every entity touched by a deletion
+func (s *AccountService) createAuditEntities(ctx context.Context, tx *sql.Tx, auditID int64, entities []models.AuditEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO admin_deletion_audit_entities
+		(audit_id, entity_type, entity_id, parent_id, previous_state)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, entity := range entities {
+		if _, err := stmt.ExecContext(ctx, auditID, entity.EntityType, entity.EntityID, entity.ParentID, []byte(entity.PreviousState)); err != nil {
+			return databaseError(fmt.Sprintf("record %s %s", entity.EntityType, entity.EntityID), err)
+		}
+	}
+
+	return nil
+}
+
+// appendAuditEntity appends an entity if the soft delete actually matched a row
+func appendAuditEntity(entities []models.AuditEntity, entityType, entityID, parentID string, previous json.RawMessage) []models.AuditEntity {
+	if previous == nil {
+		return entities
+	}
+	return append(entities, models.AuditEntity{
+		EntityType:    entityType,
+		EntityID:      entityID,
+		ParentID:      parentID,
+		PreviousState: previous,
+	})
+}
+
+// GetAuditLogs searches audit logs, newest first, using keyset pagination
+// on (created_at, id) so deep pages cost the same as the first
+func (s *AccountService) GetAuditLogs(ctx context.Context, filter models.AuditLogFilter) (_ *models.AuditLogPage, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.GetAuditLogs")
+	defer func() { endSpan(span, err) }()
+
+	where, args := buildAuditLogFilter(filter)
+
+	// Count matches before applying the cursor so the total is stable across pages
+	var total int
+	countQuery := `SELECT COUNT(*) FROM admin_deletion_audit_log` + where.String()
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, databaseError("count audit logs", err)
+	}
+
+	if filter.Cursor != "" {
+		cursorTime, cursorID, err := decodeAuditCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, cursorTime, cursorID)
+		where.add(fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	args = append(args, filter.Limit+1)
+	query := `
+		SELECT ` + auditLogColumns + `
+		FROM admin_deletion_audit_log` + where.String() + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, databaseError("get audit logs", err)
+	}
+	defer rows.Close()
+
+	logs := make([]models.AuditLog, 0)
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, *log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &models.AuditLogPage{
+		Logs:  logs,
+		Total: total,
+		Limit: filter.Limit,
+	}
+	if len(logs) > filter.Limit {
+		page.Logs = logs[:filter.Limit]
+		last := page.Logs[len(page.Logs)-1]
+		page.NextCursor = encodeAuditCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+// GetAuditLog retrieves a single audit log entry with every affected entity
+func (s *AccountService) GetAuditLog(ctx context.Context, id int64) (_ *models.AuditLog, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.GetAuditLog", trace.WithAttributes(attribute.Int64("audit.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		SELECT ` + auditLogColumns + `
+		FROM admin_deletion_audit_log
+		WHERE id = $1
+	`
+
+	log, err := scanAuditLog(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAuditLogNotFound
+	}
+	if err != nil {
+		return nil, databaseError("get audit log", err)
+	}
+
+	entities, err := s.getAuditEntities(ctx, s.db, id)
+	if err != nil {
+		return nil, databaseError("get audit entities", err)
+	}
+
+	log.Entities = entities
+	log.CompanyIDs = make([]string, 0)
+	log.LocationIDs = make([]string, 0)
+	for _, entity := range entities {
+		switch entity.EntityType {
+		case models.EntityTypeCompany:
+			log.CompanyIDs = append(log.CompanyIDs, entity.EntityID)
+		case models.EntityTypeLocation:
+			log.LocationIDs = append(log.LocationIDs, entity.EntityID)
+		}
+	}
+
+	return log, nil
+}
+
+// getAuditEntities retrieves the entities recorded for an audit log entry
+func (s *AccountService) getAuditEntities(ctx context.Context, q queryer, auditID int64) ([]models.AuditEntity, error) {
+	query := `
+		SELECT entity_type, entity_id, parent_id, previous_state
+		FROM admin_deletion_audit_entities
+		WHERE audit_id = $1
+		ORDER BY id
+	`
+
+	rows, err := q.QueryContext(ctx, query, auditID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entities := make([]models.AuditEntity, 0)
+	for rows.Next() {
+		var entity models.AuditEntity
+		var previous []byte
+		if err := rows.Scan(&entity.EntityType, &entity.EntityID, &entity.ParentID, &previous); err != nil {
+			return nil, err
+		}
+		entity.PreviousState = json.RawMessage(previous)
+		entities = append(entities, entity)
+	}
+
+	return entities, rows.Err()
+}
+
+// auditWhere accumulates SQL conditions joined with AND
+type auditWhere []string
+
+// add appends a condition
+func (w *auditWhere) add(condition string) {
+	*w = append(*w, condition)
+}
+
+// String renders the WHERE clause, or nothing when there are no conditions
+func (w auditWhere) String() string {
+	if len(w) == 0 {
+		return ""
+	}
+	return "\n\t\tWHERE " + strings.Join(w, " AND ")
+}
+
+// ParseAuditLogFilter reads audit log search parameters from a query string
+func ParseAuditLogFilter(query url.Values) (models.AuditLogFilter, error) {
+	// Parse pagination parameters
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	filter := models.AuditLogFilter{
+		Actor:        query.Get("actor"),
+		TargetEmail:  query.Get("target_email"),
+		TargetUserID: query.Get("target_user_id"),
+		GroupID:      query.Get("group_id"),
+		Action:       query.Get("action"),
+		Reason:       query.Get("reason"),
+		Cursor:       query.Get("cursor"),
+		Limit:        limit,
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseQueryTime(from)
+		if err != nil {
+			return filter, ErrInvalidFilter.withMessage("invalid from: use RFC 3339 or YYYY-MM-DD")
+		}
+		filter.From = &t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, dateOnly, err := parseQueryTime(to)
+		if err != nil {
+			return filter, ErrInvalidFilter.withMessage("invalid to: use RFC 3339 or YYYY-MM-DD")
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+
+	return filter, nil
+}
+
+// parseQueryTime parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseQueryTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// buildAuditLogFilter converts a filter into conditions and positional args
+func buildAuditLogFilter(filter models.AuditLogFilter) (auditWhere, []interface{}) {
+	var where auditWhere
+	args := make([]interface{}, 0)
+
+	addArg := func(condition string, value interface{}) {
+		args = append(args, value)
+		where.add(fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		addArg("LOWER(deleted_by_email) = LOWER($%d)", filter.Actor)
+	}
+	if filter.TargetEmail != "" {
+		addArg("LOWER(target_email) = LOWER($%d)", filter.TargetEmail)
+	}
+	if filter.TargetUserID != "" {
+		addArg("target_user_id = $%d", filter.TargetUserID)
+	}
+	if filter.GroupID != "" {
+		addArg("$%d = ANY(group_ids)", filter.GroupID)
+	}
+	if filter.Action != "" {
+		addArg("action = $%d", filter.Action)
+	}
+	if filter.Reason != "" {
+		addArg("reason ILIKE '%%' || $%d || '%%'", escapeLike(filter.Reason))
+	}
+	if filter.From != nil {
+		addArg("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addArg("created_at < $%d", *filter.To)
+	}
+
+	return where, args
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// encodeAuditCursor encodes the position after an entry
+func encodeAuditCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + id))
+}
+
+// decodeAuditCursor decodes a cursor produced by encodeAuditCursor
+func decodeAuditCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	auditID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	return createdAt, auditID, nil
+}
+
+// scanAuditLog scans a row selected with auditLogColumns
+func scanAuditLog(row rowScanner) (*models.AuditLog, error) {
+	var log models.AuditLog
+	var groupIDs pq.StringArray
+
+	if err := row.Scan(
+		&log.ID,
+		&log.Action,
+		&log.DeletedByEmail,
+		&log.ActorType,
+		&log.TargetEmail,
+		&log.TargetUserID,
+		&groupIDs,
+		&log.Reason,
+		&log.DeletedGroups,
+		&log.DeletedCompanies,
+		&log.DeletedLocations,
+		&log.IPAddress,
+		&log.UserAgent,
+		&log.RequestID,
+		&log.SessionID,
+		&log.Query,
+		&log.CreatedAt,
+		&log.PrevHash,
+		&log.RowHash,
+	); err != nil {
+		return nil, err
+	}
+
+	log.GroupIDs = []string(groupIDs)
+	return &log, nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// auditChainLockID is the Postgres advisory lock serializing chain appends
+const auditChainLockID int64 = 0x6175646974636861 // "auditcha"
+
+// auditTimestampLayout formats timestamps for hashing. Timestamps are stored
+// without time zone, so only the wall clock (to the microsecond) is hashed.
+const auditTimestampLayout = "2006-01-02T15:04:05.000000"
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// auditHashPayload is the canonical content covered by an entry's hash
+type auditHashPayload struct {
+	ID               string            `json:"id"`
+	Action           string            `json:"action"`
+	DeletedByEmail   string            `json:"deleted_by_email"`
+	ActorType        string            `json:"actor_type"`
+	TargetEmail      string            `json:"target_email"`
+	TargetUserID     string            `json:"target_user_id"`
+	GroupIDs         []string          `json:"group_ids"`
+	Reason           string            `json:"reason"`
+	DeletedGroups    int               `json:"deleted_groups"`
+	DeletedCompanies int               `json:"deleted_companies"`
+	DeletedLocations int               `json:"deleted_locations"`
+	IPAddress        string            `json:"ip_address"`
+	UserAgent        string            `json:"user_agent"`
+	RequestID        string            `json:"request_id"`
+	SessionID        string            `json:"session_id"`
+	Query            string            `json:"query,omitempty"` // Omitted when empty so entries from before access logging still verify
+	CreatedAt        string            `json:"created_at"`
+	Entities         []auditHashEntity `json:"entities"`
+}
+
+// auditHashEntity is the canonical form of an affected entity
+type auditHashEntity struct {
+	EntityType    string      `json:"entity_type"`
+	EntityID      string      `json:"entity_id"`
+	ParentID      string      `json:"parent_id"`
+	PreviousState interface{} `json:"previous_state"`
+}
+
+// sealAuditLog computes the hash of a freshly inserted entry, chained to the
+// previous entry's hash. It must run under the chain lock.
+func (s *AccountService) sealAuditLog(ctx context.Context, tx *sql.Tx, auditID int64) error {
+	// Read the entry back so the hash covers exactly what verification will read
+	log, err := scanAuditLog(tx.QueryRowContext(ctx, `SELECT `+auditLogColumns+` FROM admin_deletion_audit_log WHERE id = $1`, auditID))
+	if err != nil {
+		return err
+	}
+
+	entities, err := s.getAuditEntities(ctx, tx, auditID)
+	if err != nil {
+		return err
+	}
+
+	head, err := getAuditChainHead(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	rowHash, err := computeAuditHash(head.HeadHash, log, entities)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE admin_deletion_audit_log
+		SET prev_hash = $1, row_hash = $2
+		WHERE id = $3
+	`, head.HeadHash, rowHash, auditID)
+	if err != nil {
+		return err
+	}
+
+	// Advance the head in the same transaction so removing the newest
+	// entries leaves the chain short of it
+	_, err = tx.ExecContext(ctx, `
+		UPDATE admin_audit_chain
+		SET head_id = $1, head_hash = $2, entry_count = entry_count + 1, updated_at = NOW()
+	`, auditID, rowHash)
+	return err
+}
+
+// auditChainHead is where the hash chain starts and its newest entry
+type auditChainHead struct {
+	StartID  int64
+	HeadHash string
+	Count    int64
+}
+
+// getAuditChainHead reads the chain start and head recorded by sealing
+func getAuditChainHead(ctx context.Context, db queryer) (*auditChainHead, error) {
+	var head auditChainHead
+	err := db.QueryRowContext(ctx, `
+		SELECT start_id, head_hash, entry_count FROM admin_audit_chain
+	`).Scan(&head.StartID, &head.HeadHash, &head.Count)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("audit chain head is missing; run the migrations")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// VerifyAuditChain walks the audit log in insertion order, recomputing each
+// entry's hash, and reports the first broken link. Entries before the
+// recorded chain start are counted as legacy and skipped; any later entry
+// without a hash breaks the chain. The chain must end at the recorded head,
+// and, when a checkpoint kept outside the database is given, its entry must
+// carry the checkpoint's hash, so removing the newest entries is detected.
+func (s *AccountService) VerifyAuditChain(ctx context.Context, checkpoint *models.AuditChainCheckpoint) (_ *models.AuditChainReport, err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.VerifyAuditChain")
+	defer func() { endSpan(span, err) }()
+
+	head, err := getAuditChainHead(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+auditLogColumns+`
+		FROM admin_deletion_audit_log
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifier := newAuditChainVerifier(head, checkpoint)
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		auditID, err := strconv.ParseInt(log.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		var entities []models.AuditEntity
+		if auditID >= head.StartID {
+			entities, err = s.getAuditEntities(ctx, s.db, auditID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ok, err := verifier.add(auditID, log, entities)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return verifier.report, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return verifier.finish(), nil
+}
+
+// auditChainVerifier checks audit log entries, fed in id order, against the
+// recorded chain start and head and an optional checkpoint
+type auditChainVerifier struct {
+	head       *auditChainHead
+	checkpoint *models.AuditChainCheckpoint
+	report     *models.AuditChainReport
+	prevHash   string
+}
+
+func newAuditChainVerifier(head *auditChainHead, checkpoint *models.AuditChainCheckpoint) *auditChainVerifier {
+	return &auditChainVerifier{
+		head:       head,
+		checkpoint: checkpoint,
+		report:     &models.AuditChainReport{Valid: true, StartID: head.StartID},
+	}
+}
+
+// add checks the next entry and returns false once the chain is broken
+func (v *auditChainVerifier) add(auditID int64, log *models.AuditLog, entities []models.AuditEntity) (bool, error) {
+	if auditID < v.head.StartID {
+		v.report.LegacyEntries++
+		return true, nil
+	}
+	if log.RowHash == "" {
+		brokenChain(v.report, log.ID, "entry has no hash")
+		return false, nil
+	}
+	if log.PrevHash != v.prevHash {
+		brokenChain(v.report, log.ID, "previous hash does not match the preceding entry (an entry was removed or reordered)")
+		return false, nil
+	}
+
+	expected, err := computeAuditHash(v.prevHash, log, entities)
+	if err != nil {
+		return false, err
+	}
+	if expected != log.RowHash {
+		brokenChain(v.report, log.ID, "entry content does not match its hash (the entry or its entities were modified)")
+		return false, nil
+	}
+
+	v.prevHash = log.RowHash
+	v.report.CheckedEntries++
+	v.report.LastHash = log.RowHash
+
+	if v.checkpoint != nil && v.report.CheckedEntries == v.checkpoint.Count && log.RowHash != v.checkpoint.Hash {
+		brokenChain(v.report, log.ID, "entry does not match the checkpoint (the chain was rewritten)")
+		return false, nil
+	}
+	return true, nil
+}
+
+// finish fails a chain that ends before the recorded head or the
+// checkpoint, which happens when the newest entries are removed
+func (v *auditChainVerifier) finish() *models.AuditChainReport {
+	report := v.report
+	if !report.Valid {
+		return report
+	}
+	if report.CheckedEntries != v.head.Count || report.LastHash != v.head.HeadHash {
+		return brokenChain(report, "", fmt.Sprintf("chain ends after %d entries but the recorded head is entry %d (the newest entries were removed)", report.CheckedEntries, v.head.Count))
+	}
+	if v.checkpoint != nil && report.CheckedEntries < v.checkpoint.Count {
+		return brokenChain(report, "", fmt.Sprintf("chain ends after %d entries but the checkpoint is entry %d (the newest entries were removed)", report.CheckedEntries, v.checkpoint.Count))
+	}
+	return report
+}
+
+// ParseAuditChainCheckpoint parses the entry count and hash of a report kept
+// from an earlier verification. Both empty means no checkpoint.
+func ParseAuditChainCheckpoint(count, hash string) (*models.AuditChainCheckpoint, error) {
+	if count == "" && hash == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || n < 1 {
+		return nil, InvalidInput("checkpoint count must be a positive number of entries")
+	}
+	if len(hash) != sha256.Size*2 {
+		return nil, InvalidInput("checkpoint hash must be a hex SHA-256")
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return nil, InvalidInput("checkpoint hash must be a hex SHA-256")
+	}
+	return &models.AuditChainCheckpoint{Count: n, Hash: hash}, nil
+}
+
+// brokenChain marks the report invalid at the given entry
+func brokenChain(report *models.AuditChainReport, auditID, reason string) *models.AuditChainReport {
+	report.Valid = false
+	report.FirstBrokenID = auditID
+	report.Reason = reason
+	return report
+}
+
+// computeAuditHash returns the hex SHA-256 of the previous hash and the
+// canonical JSON of the entry and its entities
+func computeAuditHash(prevHash string, log *models.AuditLog, entities []models.AuditEntity) (string, error) {
+	payload := auditHashPayload{
+		ID:               log.ID,
+		Action:           log.Action,
+		DeletedByEmail:   log.DeletedByEmail,
+		ActorType:        log.ActorType,
+		TargetEmail:      log.TargetEmail,
+		TargetUserID:     log.TargetUserID,
+		GroupIDs:         log.GroupIDs,
+		Reason:           log.Reason,
+		DeletedGroups:    log.DeletedGroups,
+		DeletedCompanies: log.DeletedCompanies,
+		DeletedLocations: log.DeletedLocations,
+		IPAddress:        log.IPAddress,
+		UserAgent:        log.UserAgent,
+		RequestID:        log.RequestID,
+		SessionID:        log.SessionID,
+		Query:            log.Query,
+		CreatedAt:        log.CreatedAt.Format(auditTimestampLayout),
+		Entities:         make([]auditHashEntity, 0, len(entities)),
+	}
+
+	for _, entity := range entities {
+		// JSONB does not preserve key order or whitespace, so hash a
+		// re-encoded form (encoding/json sorts object keys)
+		var state interface{}
+		if err := json.Unmarshal(entity.PreviousState, &state); err != nil {
+			return "", fmt.Errorf("invalid previous state for %s %s: %w", entity.EntityType, entity.EntityID, err)
+		}
+		payload.Entities = append(payload.Entities, auditHashEntity{
+			EntityType:    entity.EntityType,
+			EntityID:      entity.EntityID,
+			ParentID:      entity.ParentID,
+			PreviousState: state,
+		})
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), encoded...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// testAuditChain returns one legacy entry followed by n sealed entries, and
+// the head recorded for them
+func testAuditChain(t *testing.T, n int) ([]*models.AuditLog, map[string][]models.AuditEntity, *auditChainHead) {
+	t.Helper()
+
+	logs := []*models.AuditLog{{ID: "1", Action: models.AuditActionAccountDeletion, Reason: "before chaining"}}
+	entities := map[string][]models.AuditEntity{}
+	head := &auditChainHead{StartID: 2}
+
+	for i := 0; i < n; i++ {
+		log := &models.AuditLog{
+			ID:             strconv.Itoa(i + 2),
+			Action:         models.AuditActionAccountDeletion,
+			DeletedByEmail: "admin@example.com",
+			TargetEmail:    "user" + strconv.Itoa(i) + "@example.com",
+			Reason:         "requested by customer",
+			CreatedAt:      time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+		}
+		entities[log.ID] = []models.AuditEntity{{
+			EntityType:    "group",
+			EntityID:      "g" + strconv.Itoa(i),
+			PreviousState: json.RawMessage(`{"name":"Group","active":true}`),
+		}}
+
+		hash, err := computeAuditHash(head.HeadHash, log, entities[log.ID])
+		if err != nil {
+			t.Fatal(err)
+		}
+		log.PrevHash = head.HeadHash
+		log.RowHash = hash
+		head.HeadHash = hash
+		head.Count++
+		logs = append(logs, log)
+	}
+	return logs, entities, head
+}
+
+// verifyTestChain runs the verifier over logs the way VerifyAuditChain does
+func verifyTestChain(t *testing.T, logs []*models.AuditLog, entities map[string][]models.AuditEntity, head *auditChainHead, checkpoint *models.AuditChainCheckpoint) *models.AuditChainReport {
+	t.Helper()
+
+	verifier := newAuditChainVerifier(head, checkpoint)
+	for _, log := range logs {
+		id, err := strconv.ParseInt(log.ID, 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := verifier.add(id, log, entities[log.ID])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return verifier.report
+		}
+	}
+	return verifier.finish()
+}
+
+func TestComputeAuditHash(t *testing.T) {
+	logs, entities, _ := testAuditChain(t, 1)
+	log := logs[1]
+
+	hash, err := computeAuditHash("", log, entities[log.ID])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hash) != 64 {
+		t.Fatalf("hash length = %d, want 64", len(hash))
+	}
+
+	// JSONB does not keep key order or whitespace
+	reordered := []models.AuditEntity{entities[log.ID][0]}
+	reordered[0].PreviousState = json.RawMessage(`{ "active": true, "name": "Group" }`)
+	if got, _ := computeAuditHash("", log, reordered); got != hash {
+		t.Error("hash changed when the entity state was re-encoded")
+	}
+
+	changed := *log
+	changed.Reason = "edited"
+	if got, _ := computeAuditHash("", &changed, entities[log.ID]); got == hash {
+		t.Error("hash did not change with the entry content")
+	}
+	if got, _ := computeAuditHash(strings.Repeat("0", 64), log, entities[log.ID]); got == hash {
+		t.Error("hash did not change with the previous hash")
+	}
+
+	invalid := []models.AuditEntity{{EntityType: "group", EntityID: "g", PreviousState: json.RawMessage(`{`)}}
+	if _, err := computeAuditHash("", log, invalid); err == nil {
+		t.Error("expected an error for invalid entity state")
+	}
+}
+
+func TestAuditChainVerifier(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(logs []*models.AuditLog, entities map[string][]models.AuditEntity, head *auditChainHead) []*models.AuditLog
+		checkpoint func(logs []*models.AuditLog) *models.AuditChainCheckpoint
+		wantValid  bool
+		wantID     string
+		wantReason string
+	}{
+		{
+			name:      "intact",
+			wantValid: true,
+		},
+		{
+			name: "intact with checkpoint",
+			checkpoint: func(logs []*models.AuditLog) *models.AuditChainCheckpoint {
+				return &models.AuditChainCheckpoint{Count: 2, Hash: logs[2].RowHash}
+			},
+			wantValid: true,
+		},
+		{
+			name: "modified entry",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				logs[2].Reason = "edited"
+				return logs
+			},
+			wantID:     "3",
+			wantReason: "content does not match",
+		},
+		{
+			name: "modified entity",
+			tamper: func(logs []*models.AuditLog, entities map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				entities["2"][0].PreviousState = json.RawMessage(`{"name":"Other"}`)
+				return logs
+			},
+			wantID:     "2",
+			wantReason: "content does not match",
+		},
+		{
+			name: "one hash blanked",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				logs[3].RowHash = ""
+				return logs
+			},
+			wantID:     "4",
+			wantReason: "no hash",
+		},
+		{
+			name: "every hash blanked",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				for _, log := range logs {
+					log.PrevHash, log.RowHash = "", ""
+				}
+				return logs
+			},
+			wantID:     "2",
+			wantReason: "no hash",
+		},
+		{
+			name: "middle entry removed",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				return append(logs[:2], logs[3:]...)
+			},
+			wantID:     "4",
+			wantReason: "previous hash",
+		},
+		{
+			name: "newest entry removed",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, _ *auditChainHead) []*models.AuditLog {
+				return logs[:len(logs)-1]
+			},
+			wantReason: "newest entries were removed",
+		},
+		{
+			name: "newest entry and head rewritten",
+			tamper: func(logs []*models.AuditLog, _ map[string][]models.AuditEntity, head *auditChainHead) []*models.AuditLog {
+				logs = logs[:len(logs)-1]
+				head.Count--
+				head.HeadHash = logs[len(logs)-1].RowHash
+				return logs
+			},
+			checkpoint: func(logs []*models.AuditLog) *models.AuditChainCheckpoint {
+				return &models.AuditChainCheckpoint{Count: 3, Hash: logs[3].RowHash}
+			},
+			wantReason: "checkpoint is entry 3",
+		},
+		{
+			name: "checkpoint hash differs",
+			checkpoint: func(logs []*models.AuditLog) *models.AuditChainCheckpoint {
+				return &models.AuditChainCheckpoint{Count: 1, Hash: logs[2].RowHash}
+			},
+			wantID:     "2",
+			wantReason: "does not match the checkpoint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, entities, head := testAuditChain(t, 3)
+
+			// Checkpoints are taken from the untampered chain
+			var checkpoint *models.AuditChainCheckpoint
+			if tt.checkpoint != nil {
+				checkpoint = tt.checkpoint(logs)
+			}
+			if tt.tamper != nil {
+				logs = tt.tamper(logs, entities, head)
+			}
+
+			report := verifyTestChain(t, logs, entities, head, checkpoint)
+			if report.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v (reason %q)", report.Valid, tt.wantValid, report.Reason)
+			}
+			if report.FirstBrokenID != tt.wantID {
+				t.Errorf("FirstBrokenID = %q, want %q", report.FirstBrokenID, tt.wantID)
+			}
+			if !strings.Contains(report.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", report.Reason, tt.wantReason)
+			}
+			if report.LegacyEntries != 1 {
+				t.Errorf("LegacyEntries = %d, want 1", report.LegacyEntries)
+			}
+			if tt.wantValid && (report.CheckedEntries != 3 || report.LastHash != head.HeadHash) {
+				t.Errorf("report ends at %d/%s, want 3/%s", report.CheckedEntries, report.LastHash, head.HeadHash)
+			}
+		})
+	}
+}
+
+func TestParseAuditChainCheckpoint(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name    string
+		count   string
+		hash    string
+		want    *models.AuditChainCheckpoint
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "valid", count: "12", hash: hash, want: &models.AuditChainCheckpoint{Count: 12, Hash: hash}},
+		{name: "count only", count: "12", wantErr: true},
+		{name: "hash only", hash: hash, wantErr: true},
+		{name: "zero count", count: "0", hash: hash, wantErr: true},
+		{name: "short hash", count: "1", hash: "abcd", wantErr: true},
+		{name: "not hex", count: "1", hash: strings.Repeat("zz", 32), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuditChainCheckpoint(tt.count, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if KindOf(err) != KindValidation {
+					t.Errorf("error kind = %v, want validation", KindOf(err))
+				}
+				return
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+func TestParseAuditLogFilter(t *testing.T) {
+	date := func(s string) *time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    models.AuditLogFilter
+		wantErr string
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  models.AuditLogFilter{Limit: 50},
+		},
+		{
+			name:  "every field",
+			query: "actor=a@example.com&target_email=o@example.org&target_user_id=u1&group_id=g1&action=ACCOUNT_DELETION&reason=spam&cursor=abc&limit=10",
+			want: models.AuditLogFilter{
+				Actor:        "a@example.com",
+				TargetEmail:  "o@example.org",
+				TargetUserID: "u1",
+				GroupID:      "g1",
+				Action:       "ACCOUNT_DELETION",
+				Reason:       "spam",
+				Cursor:       "abc",
+				Limit:        10,
+			},
+		},
+		{name: "limit too high", query: "limit=500", want: models.AuditLogFilter{Limit: 50}},
+		{name: "limit zero", query: "limit=0", want: models.AuditLogFilter{Limit: 50}},
+		{name: "limit not a number", query: "limit=ten", want: models.AuditLogFilter{Limit: 50}},
+		{
+			name:  "dates include the whole to day",
+			query: "from=2026-07-01&to=2026-09-30",
+			want:  models.AuditLogFilter{Limit: 50, From: date("2026-07-01T00:00:00Z"), To: date("2026-10-01T00:00:00Z")},
+		},
+		{
+			name:  "timestamps are exact",
+			query: "from=2026-07-01T10:00:00Z&to=2026-07-01T12:30:00%2B02:00",
+			want:  models.AuditLogFilter{Limit: 50, From: date("2026-07-01T10:00:00Z"), To: date("2026-07-01T12:30:00+02:00")},
+		},
+		{name: "invalid from", query: "from=yesterday", wantErr: "invalid from"},
+		{name: "invalid to", query: "to=2026-13-01", wantErr: "invalid to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseAuditLogFilter(query)
+			if tt.wantErr != "" {
+				if KindOf(err) != KindValidation || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want a validation error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (got.From == nil) != (tt.want.From == nil) || (got.From != nil && !got.From.Equal(*tt.want.From)) {
+				t.Errorf("from = %v, want %v", got.From, tt.want.From)
+			}
+			if (got.To == nil) != (tt.want.To == nil) || (got.To != nil && !got.To.Equal(*tt.want.To)) {
+				t.Errorf("to = %v, want %v", got.To, tt.want.To)
+			}
+			got.From, got.To, tt.want.From, tt.want.To = nil, nil, nil, nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditCursor(t *testing.T) {
+	createdAt := time.Date(2026, 7, 1, 10, 0, 0, 123456000, time.UTC)
+	cursor := encodeAuditCursor(createdAt, "42")
+
+	gotTime, gotID, err := decodeAuditCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotTime.Equal(createdAt) || gotID != 42 {
+		t.Errorf("decoded %v %d, want %v 42", gotTime, gotID, createdAt)
+	}
+
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"no separator", encode("2026-07-01T10:00:00Z")},
+		{"bad time", encode("yesterday|42")},
+		{"bad id", encode("2026-07-01T10:00:00Z|abc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeAuditCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestBuildAuditLogFilter(t *testing.T) {
+	from := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	where, args := buildAuditLogFilter(models.AuditLogFilter{
+		Actor:  "a@example.com",
+		Action: "ACCOUNT_DELETION",
+		Reason: "100%_off",
+		From:   &from,
+	})
+
+	wantWhere := "\n\t\tWHERE LOWER(deleted_by_email) = LOWER($1) AND action = $2 AND reason ILIKE '%' || $3 || '%' AND created_at >= $4"
+	if got := where.String(); got != wantWhere {
+		t.Errorf("where = %q, want %q", got, wantWhere)
+	}
+	wantArgs := []interface{}{"a@example.com", "ACCOUNT_DELETION", `100\%\_off`, from}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+
+	if where, args := buildAuditLogFilter(models.AuditLogFilter{}); where.String() != "" || len(args) != 0 {
+		t.Errorf("empty filter = %q %v", where.String(), args)
+	}
+}
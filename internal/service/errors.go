@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// ErrorKind classifies a service error; handlers map each kind to an HTTP
+// status
+type ErrorKind int
+
+// Error kinds
+const (
+	KindInternal ErrorKind = iota
+	KindNotFound
+	KindConflict
+	KindAlreadyDeleted
+	KindValidation
+	KindPreconditionFailed
+	KindUnavailable
+)
+
+// String returns the kind in snake case, as used in metric labels
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindAlreadyDeleted:
+		return "already_deleted"
+	case KindValidation:
+		return "validation"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	case KindUnavailable:
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a service error with a stable machine-readable code and a message
+// that is safe to show to callers. The underlying cause, if any, is kept for
+// logs but never shown.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is matches errors with the same kind and code, so a sentinel matches every
+// error derived from it whatever its message
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// withMessage returns a copy of e with a more specific message
+func (e *Error) withMessage(format string, args ...interface{}) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: fmt.Sprintf(format, args...), Err: e.Err}
+}
+
+// wrap returns a copy of e caused by err
+func (e *Error) wrap(err error) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Err: err}
+}
+
+// newError creates a sentinel service error
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// InvalidInput reports a malformed request, such as a body that does not bind
+func InvalidInput(message string) error {
+	return newError(KindValidation, "invalid_request", message)
+}
+
+// PublicError returns the code and message of err that are safe to show
+// outside the service. Errors other than service errors, and internal ones,
+// are reported as a generic internal error so their cause never leaks.
+func PublicError(err error) (code, message string) {
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Kind == KindInternal {
+		return "internal_error", "internal server error"
+	}
+	return serviceErr.Code, serviceErr.Message
+}
+
+// KindOf returns the kind of a service error, or KindInternal for any other error
+func KindOf(err error) ErrorKind {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+	return KindInternal
+}
+
+var (
+	// ErrAccountNotFound is returned when no active account matches
+	ErrAccountNotFound = newError(KindNotFound, "account_not_found", "account not found")
+
+	// ErrAccountAlreadyDeleted is returned when deleting an account that is already deleted
+	ErrAccountAlreadyDeleted = newError(KindAlreadyDeleted, "account_already_deleted", "account is already deleted")
+
+	// ErrAccountMismatch is returned when a deletion's user ID and email no
+	// longer belong to the same account
+	ErrAccountMismatch = newError(KindPreconditionFailed, "account_mismatch", "user ID does not belong to this email, look the account up again")
+
+	// ErrGroupNotFound is returned when a selected group does not exist
+	ErrGroupNotFound = newError(KindNotFound, "group_not_found", "group not found")
+
+	// ErrGroupNotOwned is returned when a selected group is not owned by the account
+	ErrGroupNotOwned = newError(KindPreconditionFailed, "group_not_owned", "group is not owned by this account")
+
+	// ErrGroupAlreadyDeleted is returned when a selected group is already deleted
+	ErrGroupAlreadyDeleted = newError(KindAlreadyDeleted, "group_already_deleted", "group is already deleted")
+
+	// ErrNoGroupsSelected is returned when a deletion selects no groups
+	ErrNoGroupsSelected = newError(KindValidation, "no_groups_selected", "at least one group must be selected")
+
+	// ErrDatabaseUnavailable is returned when the database cannot be reached
+	ErrDatabaseUnavailable = newError(KindUnavailable, "database_unavailable", "database is unavailable, try again later")
+
+	// ErrConcurrentUpdate is returned when a transaction lost a race with another one
+	ErrConcurrentUpdate = newError(KindConflict, "concurrent_update", "the records were changed concurrently, try again")
+)
+
+// databaseError classifies a database failure: lost connections and server
+// shutdowns are unavailable, serialization failures and deadlocks are
+// conflicts, and anything else stays an internal error
+func databaseError(op string, err error) error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrDatabaseUnavailable.wrap(fmt.Errorf("failed to %s: %w", op, err))
+	case errors.As(err, &pqErr):
+		switch {
+		case pqErr.Code.Class() == "08" || pqErr.Code.Class() == "57":
+			return ErrDatabaseUnavailable.wrap(fmt.Errorf("failed to %s: %w", op, err))
+		case pqErr.Code.Class() == "40" || pqErr.Code == "55P03":
+			return ErrConcurrentUpdate.wrap(fmt.Errorf("failed to %s: %w", op, err))
+		}
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestPublicError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "service error",
+			err:         ErrGroupNotOwned,
+			wantCode:    "group_not_owned",
+			wantMessage: "group is not owned by this account",
+		},
+		{
+			name:        "wrapped cause is hidden",
+			err:         ErrDatabaseUnavailable.wrap(errors.New("dial tcp 10.0.0.5:5432: connection refused")),
+			wantCode:    "database_unavailable",
+			wantMessage: "database is unavailable, try again later",
+		},
+		{
+			name:        "plain error",
+			err:         errors.New(`pq: relation "groups" does not exist`),
+			wantCode:    "internal_error",
+			wantMessage: "internal server error",
+		},
+		{
+			name:        "internal service error",
+			err:         newError(KindInternal, "secret_code", "secret detail"),
+			wantCode:    "internal_error",
+			wantMessage: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, message := PublicError(tt.err)
+			if code != tt.wantCode || message != tt.wantMessage {
+				t.Errorf("PublicError = %q, %q, want %q, %q", code, message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDatabaseError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "bad connection", err: driver.ErrBadConn, want: KindUnavailable},
+		{name: "admin shutdown", err: &pq.Error{Code: "57P01"}, want: KindUnavailable},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, want: KindUnavailable},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: KindConflict},
+		{name: "lock not available", err: &pq.Error{Code: "55P03"}, want: KindConflict},
+		{name: "syntax error", err: &pq.Error{Code: "42601"}, want: KindInternal},
+		{name: "service error kept", err: ErrAccountNotFound, want: KindNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := databaseError("query", tt.err)
+			if got := KindOf(err); got != tt.want {
+				t.Errorf("kind = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("the cause is not kept")
+			}
+		})
+	}
+}
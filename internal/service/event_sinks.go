@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// natsTimeout bounds connecting to and publishing on the NATS broker
+const natsTimeout = 5 * time.Second
+
+// LogSink writes every event to the application log
+type LogSink struct{}
+
+// NewLogSink creates a new log sink
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string { return "log" }
+
+func (s *LogSink) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	// The payload is embedded as JSON rather than quoted
+	slog.InfoContext(ctx, "event", slog.String("event_id", event.ID), slog.String("event_type", event.Type), slog.Any("payload", json.RawMessage(payload)))
+	return nil
+}
+
+// WebhookSink hands events to the webhook service, which fans them out to
+// matching subscriptions with its own retries. Re-publishing an event is a
+// no-op for subscriptions that already have it.
+type WebhookSink struct {
+	webhookService *WebhookService
+}
+
+// NewWebhookSink creates a sink feeding webhook subscriptions
+func NewWebhookSink(webhookService *WebhookService) *WebhookSink {
+	return &WebhookSink{
+		webhookService: webhookService,
+	}
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	return s.webhookService.Emit(ctx, event)
+}
+
+// NATSSink publishes events to a NATS-compatible broker on the subject
+// <prefix>.<event type>, using the core text protocol. The event ID is sent
+// as the Nats-Msg-Id header so JetStream streams can drop redeliveries.
+type NATSSink struct {
+	addr   string
+	host   string
+	prefix string
+	tls    bool
+
+	// Credentials from the URL: user and pass, or a token alone
+	user  string
+	pass  string
+	token string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	headers bool
+}
+
+// NewNATSSink creates a sink for a nats://[user:pass@|token@]host:port URL.
+// A tls:// URL always uses TLS; a nats:// URL uses it when the broker
+// requires it.
+func NewNATSSink(rawURL, prefix string) (*NATSSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL, expected nats://host:port or tls://host:port")
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	sink := &NATSSink{
+		addr:   addr,
+		host:   u.Hostname(),
+		prefix: strings.TrimSuffix(prefix, "."),
+		tls:    u.Scheme == "tls",
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			sink.user = u.User.Username()
+			sink.pass = pass
+		} else {
+			sink.token = u.User.Username()
+		}
+	}
+	return sink, nil
+}
+
+func (s *NATSSink) Name() string { return "nats" }
+
+// Publish sends the event and waits for the broker to acknowledge it with a
+// PONG, so a nil error means the broker received it
+func (s *NATSSink) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(natsTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+
+	subject := s.prefix + "." + event.Type
+	var msg string
+	if s.headers {
+		header := "NATS/1.0\r\nNats-Msg-Id: " + event.ID + "\r\n\r\n"
+		msg = fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n", subject, len(header), len(header)+len(payload), header, payload)
+	} else {
+		msg = fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	}
+
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		s.close()
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+
+	if err := s.awaitPong(); err != nil {
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// connect dials the broker and completes the INFO/CONNECT handshake
+func (s *NATSSink) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting %q: %v", strings.TrimSpace(line), err)
+	}
+
+	var info struct {
+		Headers     bool `json:"headers"`
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "INFO ")), &info)
+
+	// The broker sends INFO in plain text, then expects the TLS handshake
+	if s.tls || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to start TLS with NATS: %w", err)
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	connectMsg, err := json.Marshal(natsConnect{
+		Headers:  info.Headers,
+		Name:     "admin-deletion-dashboard",
+		Lang:     "go",
+		Version:  "1.0.0",
+		Protocol: 1,
+		TLS:      s.tls || info.TLSRequired,
+		User:     s.user,
+		Pass:     s.pass,
+		Token:    s.token,
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if _, err := conn.Write([]byte("CONNECT " + string(connectMsg) + "\r\nPING\r\n")); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	s.conn = conn
+	s.reader = reader
+	s.headers = info.Headers
+
+	if err := s.awaitPong(); err != nil {
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// natsConnect is the CONNECT message sent after the broker's INFO
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Headers  bool   `json:"headers"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Protocol int    `json:"protocol"`
+	TLS      bool   `json:"tls_required"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+// awaitPong reads until the broker answers our PING, replying to its own
+// PINGs and failing on -ERR
+func (s *NATSSink) awaitPong() error {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read from NATS: %w", err)
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("NATS error: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// close drops the connection so the next publish reconnects
+func (s *NATSSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = nil
+	s.reader = nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/signing"
+)
+
+var (
+	// ErrExportNotFound is returned when an export manifest does not exist
+	ErrExportNotFound = newError(KindNotFound, "export_not_found", "export not found")
+
+	// ErrInvalidExportFormat is returned for an unsupported export format
+	ErrInvalidExportFormat = newError(KindValidation, "invalid_export_format", "format must be csv or jsonl")
+)
+
+// auditExportColumns are the CSV header columns, in order
+var auditExportColumns = []string{
+	"id", "created_at", "action", "query", "actor", "actor_type", "target_email", "target_user_id",
+	"group_ids", "company_ids", "location_ids", "reason", "deleted_groups", "deleted_companies",
+	"deleted_locations", "ip_address", "user_agent", "request_id", "session_id", "row_hash",
+}
+
+// ExportService streams audit log exports and signs their manifests
+type ExportService struct {
+	db     *sql.DB
+	signer signing.Signer
+}
+
+// NewExportService creates a new export service
+func NewExportService(db *sql.DB, signer signing.Signer) *ExportService {
+	return &ExportService{
+		db:     db,
+		signer: signer,
+	}
+}
+
+// NewExportID generates a random export ID
+func NewExportID() (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate export id: %w", err)
+	}
+	return id, nil
+}
+
+// ExportAuditLogs streams every audit log entry matching the filter to w,
+// oldest first, then records and returns a signed manifest of what was
+// written. Rows are written as they are read, so memory use does not grow
+// with the size of the export. The rows are counted and streamed from one
+// snapshot; recordAccess is called with the count before anything is
+// written, and its error aborts the export.
+func (s *ExportService) ExportAuditLogs(ctx context.Context, req *models.AuditExportRequest, w io.Writer, recordAccess func(rowCount int) error) (*models.ExportManifest, error) {
+	if req.Format != models.ExportFormatCSV && req.Format != models.ExportFormatJSONL {
+		return nil, ErrInvalidExportFormat
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, databaseError("start transaction", err)
+	}
+	defer tx.Rollback()
+
+	where, args := buildAuditLogFilter(req.Filter)
+
+	var total int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM admin_deletion_audit_log`+where.String(), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	// Recorded in its own transaction, so the export does not include it
+	if err := recordAccess(total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + auditLogColumns + `,
+			ARRAY(SELECT entity_id FROM admin_deletion_audit_entities e
+				WHERE e.audit_id = admin_deletion_audit_log.id AND e.entity_type = 'company' ORDER BY e.id),
+			ARRAY(SELECT entity_id FROM admin_deletion_audit_entities e
+				WHERE e.audit_id = admin_deletion_audit_log.id AND e.entity_type = 'location' ORDER BY e.id)
+		FROM admin_deletion_audit_log` + where.String() + `
+		ORDER BY created_at, id`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	// Hash exactly the bytes the caller receives
+	hash := sha256.New()
+	out := bufio.NewWriter(io.MultiWriter(w, hash))
+
+	var encode func(*models.AuditLog) error
+	switch req.Format {
+	case models.ExportFormatCSV:
+		csvWriter := csv.NewWriter(out)
+		if err := csvWriter.Write(auditExportColumns); err != nil {
+			return nil, err
+		}
+		encode = func(log *models.AuditLog) error {
+			if err := csvWriter.Write(auditExportRecord(log)); err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	case models.ExportFormatJSONL:
+		encoder := json.NewEncoder(out)
+		encode = func(log *models.AuditLog) error {
+			return encoder.Encode(log)
+		}
+	}
+
+	rowCount := 0
+	for rows.Next() {
+		var companyIDs, locationIDs pq.StringArray
+		log, err := scanAuditLog(scannerWithExtra{rows, []interface{}{&companyIDs, &locationIDs}})
+		if err != nil {
+			return nil, err
+		}
+		log.CompanyIDs = []string(companyIDs)
+		log.LocationIDs = []string(locationIDs)
+
+		if err := encode(log); err != nil {
+			return nil, fmt.Errorf("failed to write export: %w", err)
+		}
+		rowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit logs: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	manifest := &models.ExportManifest{
+		ID:        req.ID,
+		Format:    req.Format,
+		Filter:    req.Query,
+		RowCount:  rowCount,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		CreatedBy: req.RequestedBy,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Algorithm: s.signer.Algorithm(),
+		KeyID:     s.signer.KeyID(),
+	}
+	manifest.Signature = s.signer.Sign(manifestPayload(manifest))
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO admin_deletion_audit_exports
+		(id, format, filter, row_count, sha256, created_by, created_at, algorithm, key_id, signature)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, manifest.ID, manifest.Format, manifest.Filter, manifest.RowCount, manifest.SHA256,
+		manifest.CreatedBy, manifest.CreatedAt, manifest.Algorithm, manifest.KeyID, manifest.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record export manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// GetExportManifest retrieves the signed manifest of an export
+func (s *ExportService) GetExportManifest(ctx context.Context, id string) (*models.ExportManifest, error) {
+	var manifest models.ExportManifest
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, format, filter, row_count, sha256, created_by, created_at, algorithm, key_id, signature
+		FROM admin_deletion_audit_exports
+		WHERE id = $1
+	`, id).Scan(
+		&manifest.ID,
+		&manifest.Format,
+		&manifest.Filter,
+		&manifest.RowCount,
+		&manifest.SHA256,
+		&manifest.CreatedBy,
+		&manifest.CreatedAt,
+		&manifest.Algorithm,
+		&manifest.KeyID,
+		&manifest.Signature,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// VerifyExport hashes an exported file and checks it against the export's
+// stored manifest, including the manifest's own signature
+func (s *ExportService) VerifyExport(ctx context.Context, id string, file io.Reader) (*models.ExportVerification, error) {
+	manifest, err := s.GetExportManifest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	result := &models.ExportVerification{
+		ExportID:       manifest.ID,
+		SHA256:         hex.EncodeToString(hash.Sum(nil)),
+		SignatureValid: s.VerifyManifest(manifest),
+	}
+	result.HashMatches = result.SHA256 == manifest.SHA256
+	result.Valid = result.HashMatches && result.SignatureValid
+
+	return result, nil
+}
+
+// VerifyManifest reports whether a manifest's signature is valid for the
+// current signing key
+func (s *ExportService) VerifyManifest(manifest *models.ExportManifest) bool {
+	if manifest.Algorithm != s.signer.Algorithm() || manifest.KeyID != s.signer.KeyID() {
+		return false
+	}
+	return s.signer.Verify(manifestPayload(manifest), manifest.Signature)
+}
+
+// SigningKey describes the key exports are signed with
+func (s *ExportService) SigningKey() models.SigningKey {
+	return models.SigningKey{
+		Algorithm: s.signer.Algorithm(),
+		KeyID:     s.signer.KeyID(),
+		PublicKey: s.signer.PublicKey(),
+	}
+}
+
+// manifestPayload is the canonical form of a manifest covered by its signature
+func manifestPayload(m *models.ExportManifest) []byte {
+	return []byte(strings.Join([]string{
+		"admin-deletion-dashboard audit export v1",
+		"id:" + m.ID,
+		"format:" + m.Format,
+		"filter:" + m.Filter,
+		"row_count:" + strconv.Itoa(m.RowCount),
+		"sha256:" + m.SHA256,
+		"created_by:" + m.CreatedBy,
+		"created_at:" + m.CreatedAt.UTC().Format(time.RFC3339Nano),
+		"key_id:" + m.KeyID,
+	}, "\n"))
+}
+
+// auditExportRecord converts an entry into a CSV record matching auditExportColumns
+func auditExportRecord(log *models.AuditLog) []string {
+	return []string{
+		log.ID,
+		log.CreatedAt.UTC().Format(time.RFC3339Nano),
+		log.Action,
+		csvSafe(log.Query),
+		csvSafe(log.DeletedByEmail),
+		log.ActorType,
+		csvSafe(log.TargetEmail),
+		csvSafe(log.TargetUserID),
+		csvSafe(strings.Join(log.GroupIDs, ";")),
+		csvSafe(strings.Join(log.CompanyIDs, ";")),
+		csvSafe(strings.Join(log.LocationIDs, ";")),
+		csvSafe(log.Reason),
+		strconv.Itoa(log.DeletedGroups),
+		strconv.Itoa(log.DeletedCompanies),
+		strconv.Itoa(log.DeletedLocations),
+		log.IPAddress,
+		csvSafe(log.UserAgent),
+		log.RequestID,
+		log.SessionID,
+		log.RowHash,
+	}
+}
+
+// csvSafe stops spreadsheet apps from evaluating free-text values as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// scannerWithExtra scans auditLogColumns followed by extra columns
+type scannerWithExtra struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+// Scan appends the extra destinations to those of scanAuditLog
+func (s scannerWithExtra) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/signing"
+)
+
+func TestNewExportID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := NewExportID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pattern.MatchString(id) {
+			t.Fatalf("export ID %q is not 32 hex characters", id)
+		}
+		if seen[id] {
+			t.Fatalf("export ID %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	signer := signing.NewHMACSigner([]byte("test-key"))
+	service := NewExportService(nil, signer)
+
+	manifest := func() *models.ExportManifest {
+		m := &models.ExportManifest{
+			ID:        "abc",
+			Format:    models.ExportFormatCSV,
+			Filter:    "action=ACCOUNT_DELETION",
+			RowCount:  3,
+			SHA256:    "deadbeef",
+			CreatedBy: "admin@example.com",
+			CreatedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+			Algorithm: signer.Algorithm(),
+			KeyID:     signer.KeyID(),
+		}
+		m.Signature = signer.Sign(manifestPayload(m))
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*models.ExportManifest)
+		want   bool
+	}{
+		{name: "valid", want: true},
+		{name: "row count changed", mutate: func(m *models.ExportManifest) { m.RowCount = 2 }},
+		{name: "hash changed", mutate: func(m *models.ExportManifest) { m.SHA256 = "cafebabe" }},
+		{name: "filter changed", mutate: func(m *models.ExportManifest) { m.Filter = "" }},
+		{name: "other key", mutate: func(m *models.ExportManifest) { m.KeyID = "other" }},
+		{name: "bad signature", mutate: func(m *models.ExportManifest) { m.Signature = "AAAA" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := manifest()
+			if tt.mutate != nil {
+				tt.mutate(m)
+			}
+			if got := service.VerifyManifest(m); got != tt.want {
+				t.Errorf("VerifyManifest = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"owner@example.org", "owner@example.org"},
+		{"=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+	}
+
+	for _, tt := range tests {
+		if got := csvSafe(tt.value); got != tt.want {
+			t.Errorf("csvSafe(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a background job, reported by readiness checks
+type JobStatus struct {
+	Name      string     `json:"name"`
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// JobMonitor records the runs of a polling background job
+type JobMonitor struct {
+	name     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	running   bool
+	startedAt time.Time
+	lastRunAt time.Time
+	lastErr   error
+}
+
+// newJobMonitor creates a monitor for a job polling every interval
+func newJobMonitor(name string, interval time.Duration) *JobMonitor {
+	return &JobMonitor{name: name, interval: interval}
+}
+
+// start marks the job as running
+func (m *JobMonitor) start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running = true
+	m.startedAt = time.Now().UTC()
+}
+
+// stop marks the job as no longer running
+func (m *JobMonitor) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running = false
+}
+
+// record notes the end of a pass and its error, if any
+func (m *JobMonitor) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastRunAt = time.Now().UTC()
+	m.lastErr = err
+}
+
+// Status returns the job's state
+func (m *JobMonitor) Status() JobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := JobStatus{Name: m.name, Running: m.running}
+	if !m.startedAt.IsZero() {
+		startedAt := m.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !m.lastRunAt.IsZero() {
+		lastRunAt := m.lastRunAt
+		status.LastRunAt = &lastRunAt
+	}
+	if m.lastErr != nil {
+		status.LastError = m.lastErr.Error()
+	}
+	return status
+}
+
+// Healthy returns an error when the job is not running or has stopped
+// completing passes. A pass that failed still counts, as the job retries.
+func (m *JobMonitor) Healthy() error {
+	status := m.Status()
+	switch {
+	case status.StartedAt == nil:
+		return fmt.Errorf("%s has not started", m.name)
+	case !status.Running:
+		return fmt.Errorf("%s has stopped", m.name)
+	}
+
+	// Allow for slow passes before calling the job stuck
+	staleAfter := 10 * m.interval
+	if staleAfter < time.Minute {
+		staleAfter = time.Minute
+	}
+	last := *status.StartedAt
+	if status.LastRunAt != nil {
+		last = *status.LastRunAt
+	}
+	if since := time.Since(last); since > staleAfter {
+		return fmt.Errorf("%s has not completed a pass in %s", m.name, since.Round(time.Second))
+	}
+	return nil
+}
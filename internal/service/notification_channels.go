@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// notificationTimeout bounds sending one notification
+const notificationTimeout = 30 * time.Second
+
+// SMTPConfig configures the email channel
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // Optional; enables PLAIN auth
+	Password string
+	From     string
+}
+
+// EmailChannel sends notifications as plain text email over SMTP. STARTTLS
+// is used when the server offers it, and port 465 uses implicit TLS, so a
+// local mail sink without TLS works as well as a real relay.
+type EmailChannel struct {
+	config SMTPConfig
+}
+
+// NewEmailChannel creates an email channel
+func NewEmailChannel(config SMTPConfig) (*EmailChannel, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("SMTP host and from address are required")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	return &EmailChannel{config: config}, nil
+}
+
+func (c *EmailChannel) Name() string { return "email" }
+
+func (c *EmailChannel) Direct() bool { return true }
+
+// Send sends one message to all addresses in To
+func (c *EmailChannel) Send(ctx context.Context, notification *Notification) error {
+	if len(notification.To) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(c.config.Host, c.config.Port)
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if c.config.Port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: c.config.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.config.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if c.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(c.config.From); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range notification.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(c.message(notification)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message formats the email. The Message-ID is derived from the event and
+// recipients, so a resend after a lost acknowledgement can be deduplicated
+// by the receiving mail system.
+func (c *EmailChannel) message(notification *Notification) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(notification.To, ",")))
+	_, domain, _ := strings.Cut(c.config.From, "@")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(notification.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s.%s@%s>\r\n", notification.EventID, hex.EncodeToString(sum[:6]), domain)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&buf, "Content-Transfer-Encoding: 8bit\r\n")
+	fmt.Fprintf(&buf, "\r\n")
+	buf.WriteString(notification.Body)
+	return buf.Bytes()
+}
+
+// ChatChannel posts notifications to a chat incoming webhook. The payload is
+// {"text": ...}, which Slack, Google Chat and Mattermost all accept.
+type ChatChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewChatChannel creates a chat channel posting to an incoming webhook URL
+func NewChatChannel(url string) (*ChatChannel, error) {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("invalid chat webhook URL %q", url)
+	}
+	return &ChatChannel{
+		url:    url,
+		client: &http.Client{Timeout: notificationTimeout},
+	}, nil
+}
+
+func (c *ChatChannel) Name() string { return "chat" }
+
+func (c *ChatChannel) Direct() bool { return false }
+
+// Send posts the notification to the room
+func (c *ChatChannel) Send(ctx context.Context, notification *Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": "*" + notification.Subject + "*\n" + notification.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBytes))
+		return fmt.Errorf("chat webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// Notification recipients a channel preference can select
+const (
+	RecipientOperator = "operator" // Whoever requested the deletion
+	RecipientCustomer = "customer" // The account owner; email only
+)
+
+// NotifiableEvents are the events the notifier can send messages for
+var NotifiableEvents = []string{
+	models.EventAccountDeletionExecuted,
+	models.EventAccountDeletionFailed,
+}
+
+// Notification is a rendered message for one channel
+type Notification struct {
+	EventID   string
+	EventType string
+	To        []string // Email addresses; empty for chat rooms
+	Subject   string
+	Body      string
+}
+
+// NotificationChannel delivers notifications. Direct channels (email) send
+// to the addresses in To; the others post to a shared room.
+type NotificationChannel interface {
+	Name() string
+	Direct() bool
+	Send(ctx context.Context, notification *Notification) error
+}
+
+// NotificationPreference selects which events a channel is told about and,
+// for direct channels, who receives them
+type NotificationPreference struct {
+	Events     []string // Defaults to NotifiableEvents
+	Recipients []string // operator and/or customer
+	Addresses  []string // Always notified, e.g. a team mailing list
+}
+
+// ParseNotificationPreference validates the events and recipients of a channel
+func ParseNotificationPreference(events, recipients, addresses []string) (NotificationPreference, error) {
+	if len(events) == 0 {
+		events = NotifiableEvents
+	}
+	for _, event := range events {
+		if !containsString(NotifiableEvents, event) {
+			return NotificationPreference{}, fmt.Errorf("cannot notify on %q, expected one of %s", event, strings.Join(NotifiableEvents, ", "))
+		}
+	}
+	for _, recipient := range recipients {
+		switch recipient {
+		case RecipientOperator, RecipientCustomer:
+		default:
+			return NotificationPreference{}, fmt.Errorf("unknown notification recipient %q, expected operator or customer", recipient)
+		}
+	}
+	return NotificationPreference{
+		Events:     events,
+		Recipients: recipients,
+		Addresses:  addresses,
+	}, nil
+}
+
+// notificationRoute is a channel and its preference
+type notificationRoute struct {
+	channel    NotificationChannel
+	preference NotificationPreference
+}
+
+// Notifier tells staff and customers about deletion outcomes. It runs as an
+// outbox EventSink, so every executed or failed deletion is
+// notified once it commits. Each message sent is recorded, so retries only
+// resend what failed.
+type Notifier struct {
+	db             *sql.DB
+	receiptService *ReceiptService
+	verifyURL      string
+	routes         []notificationRoute
+}
+
+// NewNotifier creates a notifier without channels. Customer emails include
+// the deletion receipt when receiptService is set.
+func NewNotifier(db *sql.DB, receiptService *ReceiptService, verifyURL string) *Notifier {
+	return &Notifier{
+		db:             db,
+		receiptService: receiptService,
+		verifyURL:      verifyURL,
+	}
+}
+
+// AddChannel sends notifications through channel according to preference
+func (n *Notifier) AddChannel(channel NotificationChannel, preference NotificationPreference) {
+	n.routes = append(n.routes, notificationRoute{channel: channel, preference: preference})
+}
+
+// HasChannels reports whether any channel is configured
+func (n *Notifier) HasChannels() bool {
+	return len(n.routes) > 0
+}
+
+func (n *Notifier) Name() string { return "notifier" }
+
+// notificationData is what the message templates render
+type notificationData struct {
+	models.DeletionEventData
+	EventID   string
+	EventType string
+	Outcome   string
+	Actor     string
+	Occurred  string
+	Receipt   *models.DeletionReceipt
+	VerifyURL string
+}
+
+// Publish notifies every channel subscribed to the event
+func (n *Notifier) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	if !containsString(NotifiableEvents, event.Type) {
+		return nil
+	}
+
+	var envelope struct {
+		Data models.DeletionEventData `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return fmt.Errorf("invalid deletion event: %w", err)
+	}
+
+	data := &notificationData{
+		DeletionEventData: envelope.Data,
+		EventID:           event.ID,
+		EventType:         event.Type,
+		Outcome:           strings.TrimPrefix(event.Type, "account.deletion."),
+		Actor:             event.Actor,
+		Occurred:          event.OccurredAt.Format("2006-01-02 15:04:05 MST"),
+		VerifyURL:         n.verifyURL,
+	}
+
+	var failures []string
+	for _, route := range n.routes {
+		if !containsString(route.preference.Events, event.Type) {
+			continue
+		}
+		if err := n.notify(ctx, route, data); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", route.channel.Name(), err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to notify: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// notify sends the staff message, and the customer message if selected,
+// through one channel
+func (n *Notifier) notify(ctx context.Context, route notificationRoute, data *notificationData) error {
+	channel := route.channel
+	if !channel.Direct() {
+		return n.send(ctx, channel, channel.Name(), staffNotificationTemplate, data)
+	}
+
+	var staff []string
+	if containsString(route.preference.Recipients, RecipientOperator) {
+		staff = appendAddress(staff, data.Actor)
+	}
+	for _, address := range route.preference.Addresses {
+		staff = appendAddress(staff, address)
+	}
+
+	for _, address := range staff {
+		if err := n.send(ctx, channel, address, staffNotificationTemplate, data); err != nil {
+			return err
+		}
+	}
+
+	// Customers hear about their deletion, not about internal failures
+	if containsString(route.preference.Recipients, RecipientCustomer) && data.EventType == models.EventAccountDeletionExecuted && strings.Contains(data.Email, "@") {
+		if n.receiptService != nil && data.Receipt == nil && data.AuditID != 0 {
+			receipt, err := n.receiptService.GetReceipt(ctx, data.AuditID)
+			if err != nil {
+				return fmt.Errorf("failed to build receipt: %w", err)
+			}
+			data.Receipt = receipt
+		}
+		return n.send(ctx, channel, data.Email, customerNotificationTemplate, data)
+	}
+
+	return nil
+}
+
+// send renders and sends one message unless it was already sent
+func (n *Notifier) send(ctx context.Context, channel NotificationChannel, recipient string, tmpl *template.Template, data *notificationData) error {
+	var sent bool
+	err := n.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM admin_notifications
+			WHERE event_id = $1 AND channel = $2 AND recipient = $3
+		)
+	`, data.EventID, channel.Name(), recipient).Scan(&sent)
+	if err != nil {
+		return fmt.Errorf("failed to check notification: %w", err)
+	}
+	if sent {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render notification: %w", err)
+	}
+	subject, body, _ := strings.Cut(buf.String(), "\n")
+
+	notification := &Notification{
+		EventID:   data.EventID,
+		EventType: data.EventType,
+		Subject:   strings.TrimSpace(strings.TrimPrefix(subject, "Subject:")),
+		Body:      strings.TrimLeft(body, "\n"),
+	}
+	if channel.Direct() {
+		notification.To = []string{recipient}
+	}
+
+	if err := channel.Send(ctx, notification); err != nil {
+		return err
+	}
+
+	_, err = n.db.ExecContext(ctx, `
+		INSERT INTO admin_notifications (event_id, event_type, channel, recipient)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, channel, recipient) DO NOTHING
+	`, data.EventID, data.EventType, channel.Name(), recipient)
+	if err != nil {
+		// Sent but not recorded; a retry may send it again
+		slog.ErrorContext(ctx, "failed to record notification", slog.String("event_id", data.EventID), slog.String("channel", channel.Name()), logging.Err(err))
+	}
+	return nil
+}
+
+// appendAddress adds an email address once, skipping API key actors
+func appendAddress(addresses []string, address string) []string {
+	address = strings.TrimSpace(address)
+	if !strings.Contains(address, "@") || containsString(addresses, address) {
+		return addresses
+	}
+	return append(addresses, address)
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Templates render "Subject: ..." on the first line, then the body
+
+// staffNotificationTemplate is sent to operators and chat rooms
+var staffNotificationTemplate = template.Must(template.New("staff").Parse(`Subject: Account deletion {{.Outcome}}: {{.Email}}
+{{if eq .Outcome "executed"}}The account {{.Email}} ({{.UserID}}) was deleted.
+{{else}}The deletion of {{.Email}} ({{.UserID}}) failed and was rolled back. Nothing was deleted.
+{{end}}
+Requested by: {{.Actor}}
+Reason:       {{.Reason}}
+Groups:       {{range $i, $id := .GroupIDs}}{{if $i}}, {{end}}{{$id}}{{end}}
+{{if eq .Outcome "executed"}}Deleted:      {{.DeletedGroups}} groups, {{.DeletedCompanies}} companies, {{.DeletedLocations}} locations
+{{end}}{{if .AuditID}}Audit entry:  #{{.AuditID}}
+{{end}}{{if .Error}}Error:        {{.Error}} ({{.ErrorCode}})
+{{end}}
+Event {{.EventID}} at {{.Occurred}}
+`))
+
+// customerNotificationTemplate is sent to the account owner once the
+// deletion is executed
+var customerNotificationTemplate = template.Must(template.New("customer").Parse(`Subject: Your Appointy account has been deleted
+Hello,
+
+As requested, your Appointy account {{.Email}} and the business data it owned have been deleted.
+{{with .Receipt}}
+Deletion receipt: {{.Reference}}
+Deleted at:       {{.DeletedAt.Format "2006-01-02 15:04:05 MST"}}
+Scope:            {{.TotalGroups}} groups, {{.TotalCompanies}} companies, {{.TotalLocations}} locations
+{{if $.VerifyURL}}
+To confirm this receipt is genuine, send its reference and signature to {{$.VerifyURL}}.
+{{end}}Signature ({{.Algorithm}}, key {{.KeyID}}):
+{{.Signature}}
+{{end}}
+If you did not expect this message, please contact Appointy support.
+`))
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+func TestParseNotificationPreference(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     []string
+		recipients []string
+		wantEvents []string
+		wantErr    string
+	}{
+		{
+			name:       "defaults to every event",
+			recipients: []string{RecipientOperator},
+			wantEvents: NotifiableEvents,
+		},
+		{
+			name:       "some events",
+			events:     []string{models.EventAccountDeletionFailed},
+			recipients: []string{RecipientOperator, RecipientCustomer},
+			wantEvents: []string{models.EventAccountDeletionFailed},
+		},
+		{
+			name:    "event that is not notified",
+			events:  []string{models.EventAccountLookup},
+			wantErr: "cannot notify",
+		},
+		{
+			name:    "unknown event",
+			events:  []string{"account.deletion.restored"},
+			wantErr: "cannot notify",
+		},
+		{
+			name:       "unknown recipient",
+			recipients: []string{"approver"},
+			wantErr:    "unknown notification recipient",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := []string{"team@example.com"}
+			got, err := ParseNotificationPreference(tt.events, tt.recipients, addresses)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", got.Events, tt.wantEvents)
+			}
+			if !reflect.DeepEqual(got.Recipients, tt.recipients) || !reflect.DeepEqual(got.Addresses, addresses) {
+				t.Errorf("got %+v", got)
+			}
+		})
+	}
+}
+
+func TestNotificationTemplates(t *testing.T) {
+	data := &notificationData{
+		DeletionEventData: models.DeletionEventData{
+			UserID:   "u1",
+			Email:    "owner@example.org",
+			GroupIDs: []string{"g1", "g2"},
+			Reason:   "requested by customer",
+			AuditID:  42,
+		},
+		EventID: "evt_1",
+		Actor:   "admin@example.com",
+	}
+
+	render := func(tmpl *template.Template, outcome string, mutate func(*notificationData)) string {
+		t.Helper()
+		d := *data
+		d.Outcome = outcome
+		if mutate != nil {
+			mutate(&d)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &d); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "staff executed",
+			message: render(staffNotificationTemplate, "executed", nil),
+			want:    []string{"Subject: Account deletion executed: owner@example.org", "was deleted", "g1, g2", "Audit entry:  #42"},
+			notWant: []string{"Error:"},
+		},
+		{
+			name: "staff failed",
+			message: render(staffNotificationTemplate, "failed", func(d *notificationData) {
+				d.ErrorCode, d.Error = "internal_error", "internal server error"
+			}),
+			want:    []string{"failed and was rolled back", "Error:        internal server error (internal_error)"},
+			notWant: []string{"Deleted:"},
+		},
+		{
+			name: "customer with receipt",
+			message: render(customerNotificationTemplate, "executed", func(d *notificationData) {
+				d.Receipt = &models.DeletionReceipt{Reference: "DR-42-abc", Signature: "sig"}
+				d.VerifyURL = "https://example.com/verify"
+			}),
+			want:    []string{"Subject: Your Appointy account has been deleted", "Deletion receipt: DR-42-abc", "https://example.com/verify"},
+			notWant: []string{"restored", "requested by customer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.want {
+				if !strings.Contains(tt.message, want) {
+					t.Errorf("message does not contain %q:\n%s", want, tt.message)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(tt.message, notWant) {
+					t.Errorf("message contains %q:\n%s", notWant, tt.message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+const (
+	outboxBatchSize    = 50
+	outboxPollInterval = 2 * time.Second
+	outboxBaseBackoff  = 5 * time.Second
+	outboxMaxBackoff   = 10 * time.Minute
+	outboxMaxAttempts  = 12 // about an hour of retries
+)
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// EventSink publishes outbox events to a downstream system. Publish may be
+// called more than once for the same event, so sinks pass the event ID on
+// for deduplication.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, event *models.Event, payload []byte) error
+}
+
+// enqueueEvent writes an event to the outbox. Pass the transaction making the
+// change the event announces, so the event exists if and only if it commits.
+func enqueueEvent(ctx context.Context, db execer, event *models.Event) error {
+	if event.ID == "" {
+		id, err := NewEventID()
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO admin_event_outbox (event_id, event_type, payload)
+		VALUES ($1, $2, $3)
+	`, event.ID, event.Type, payload)
+	if err != nil {
+		return fmt.Errorf("failed to write %s event to outbox: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+// EnqueueEvent writes an event that is not part of a larger transaction,
+// such as an account lookup, to the outbox
+func (s *AccountService) EnqueueEvent(ctx context.Context, event *models.Event) (err error) {
+	ctx, span := tracer.Start(ctx, "AccountService.EnqueueEvent")
+	defer func() { endSpan(span, err) }()
+
+	return enqueueEvent(ctx, s.db, event)
+}
+
+// OutboxRelay publishes outbox events to every sink. Each sink that accepts
+// an event is recorded, and only the sinks that failed are retried; an event
+// still failing after the last attempt is dead-lettered. A sink can still see
+// an event twice if recording its delivery fails, so delivery is
+// at-least-once and sinks must deduplicate by event ID.
+type OutboxRelay struct {
+	db      *sql.DB
+	sinks   []EventSink
+	monitor *JobMonitor
+}
+
+// NewOutboxRelay creates a relay publishing to the given sinks
+func NewOutboxRelay(db *sql.DB, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{
+		db:      db,
+		sinks:   sinks,
+		monitor: newJobMonitor("outbox relay", outboxPollInterval),
+	}
+}
+
+// Monitor returns the relay's job status
+func (r *OutboxRelay) Monitor() *JobMonitor {
+	return r.monitor
+}
+
+// Run publishes pending events until ctx is cancelled. On cancellation the
+// event being published is finished and the rest of its batch is released.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	r.monitor.start()
+	defer r.monitor.stop()
+	ctx = logging.With(ctx, logging.Job(r.monitor.name))
+
+	for {
+		for ctx.Err() == nil {
+			n, err := r.relayPending(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "outbox relay failed", logging.Err(err))
+			}
+			if err != nil || n < outboxBatchSize {
+				r.monitor.record(err)
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// outboxRow is a claimed outbox event
+type outboxRow struct {
+	id        int64
+	attempts  int
+	payload   []byte
+	delivered []string // Sinks that already accepted the event
+}
+
+// relayPending claims a batch of due events in insertion order and publishes
+// each one. Claimed rows are pushed back by a lease so other instances skip
+// them meanwhile.
+func (r *OutboxRelay) relayPending(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE admin_event_outbox o
+		SET next_attempt_at = NOW() + INTERVAL '1 minute'
+		WHERE id IN (
+			SELECT id FROM admin_event_outbox
+			WHERE published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, attempts, payload,
+			ARRAY(SELECT sink FROM admin_event_outbox_deliveries d WHERE d.outbox_id = o.id)
+	`, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := make([]outboxRow, 0, outboxBatchSize)
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.id, &row.attempts, &row.payload, (*pq.StringArray)(&row.delivered)); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// An event in hand is published even if the relay is stopped meanwhile
+	work := context.WithoutCancel(ctx)
+	for i, row := range batch {
+		if ctx.Err() != nil {
+			ids := make([]int64, 0, len(batch)-i)
+			for _, rest := range batch[i:] {
+				ids = append(ids, rest.id)
+			}
+			r.release(work, ids)
+			return i, nil
+		}
+		if err := r.publish(work, row); err != nil {
+			slog.ErrorContext(ctx, "failed to record outbox event", slog.Int64("event_id", row.id), logging.Err(err))
+		}
+	}
+
+	return len(batch), nil
+}
+
+// release makes claimed events due again, so they are published right away
+// after a restart or by another instance rather than once the lease expires
+func (r *OutboxRelay) release(ctx context.Context, ids []int64) {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE admin_event_outbox SET next_attempt_at = NOW()
+		WHERE id = ANY($1) AND published_at IS NULL AND dead_lettered_at IS NULL
+	`, pq.Array(ids))
+	if err != nil {
+		// Left claimed; they are picked up again once the lease expires
+		slog.ErrorContext(ctx, "failed to release outbox events", logging.Err(err))
+		return
+	}
+	slog.InfoContext(ctx, "released outbox events for restart", slog.Int("count", len(ids)))
+}
+
+// publish sends one event to every sink that has not accepted it yet and
+// records the outcome, scheduling a retry with exponential backoff or
+// dead-lettering the event after the last attempt
+func (r *OutboxRelay) publish(ctx context.Context, row outboxRow) error {
+	// Keep the payload byte-for-byte; only the envelope is decoded
+	var envelope struct {
+		models.Event
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(row.payload, &envelope); err != nil {
+		return fmt.Errorf("invalid outbox payload: %w", err)
+	}
+	event := envelope.Event
+	event.Data = envelope.Data
+
+	var failures []string
+	for _, sink := range pendingSinks(r.sinks, row.delivered) {
+		if err := sink.Publish(ctx, &event, row.payload); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+			continue
+		}
+
+		// Recorded right away, so a crash before the outcome is written does
+		// not send the event to this sink again
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO admin_event_outbox_deliveries (outbox_id, sink)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, row.id, sink.Name())
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to record outbox delivery", slog.Int64("event_id", row.id), slog.String("sink", sink.Name()), logging.Err(err))
+		}
+	}
+
+	if len(failures) == 0 {
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE admin_event_outbox
+			SET published_at = NOW(), attempts = attempts + 1, last_error = ''
+			WHERE id = $1
+		`, row.id)
+		return err
+	}
+
+	attempts := row.attempts + 1
+	lastError := strings.Join(failures, "; ")
+	if attempts < outboxMaxAttempts {
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE admin_event_outbox
+			SET attempts = $2, last_error = $3, next_attempt_at = NOW() + $4 * INTERVAL '1 second'
+			WHERE id = $1
+		`, row.id, attempts, lastError, int(retryBackoff(attempts, outboxBaseBackoff, outboxMaxBackoff).Seconds()))
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE admin_event_outbox
+		SET attempts = $2, last_error = $3, dead_lettered_at = NOW()
+		WHERE id = $1
+	`, row.id, attempts, lastError)
+	if err != nil {
+		return err
+	}
+	slog.ErrorContext(ctx, "outbox event dead-lettered",
+		slog.Int64("event_id", row.id),
+		slog.String("event_type", event.Type),
+		slog.Int("attempts", attempts),
+		slog.String(logging.KeyError, lastError),
+	)
+	return nil
+}
+
+// pendingSinks returns the sinks that have not accepted an event yet
+func pendingSinks(sinks []EventSink, delivered []string) []EventSink {
+	pending := make([]EventSink, 0, len(sinks))
+	for _, sink := range sinks {
+		if !containsString(delivered, sink.Name()) {
+			pending = append(pending, sink)
+		}
+	}
+	return pending
+}
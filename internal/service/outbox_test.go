@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// namedSink is a sink that accepts every event
+type namedSink string
+
+func (s namedSink) Name() string { return string(s) }
+
+func (s namedSink) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	return nil
+}
+
+func TestPendingSinks(t *testing.T) {
+	sinks := []EventSink{namedSink("webhook"), namedSink("nats"), namedSink("notifier")}
+
+	tests := []struct {
+		name      string
+		delivered []string
+		want      []string
+	}{
+		{name: "none delivered", want: []string{"webhook", "nats", "notifier"}},
+		{name: "some delivered", delivered: []string{"webhook", "notifier"}, want: []string{"nats"}},
+		{name: "all delivered", delivered: []string{"notifier", "nats", "webhook"}, want: []string{}},
+		{name: "removed sink ignored", delivered: []string{"log"}, want: []string{"webhook", "nats", "notifier"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, sink := range pendingSinks(sinks, tt.delivered) {
+				got = append(got, sink.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pendingSinks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/signing"
+)
+
+// ErrNotADeletion is returned when a receipt is requested for an audit entry
+// that is not an account deletion
+var ErrNotADeletion = newError(KindValidation, "not_a_deletion", "audit log entry is not an account deletion")
+
+// receiptReferencePrefix starts every receipt reference
+const receiptReferencePrefix = "DR-"
+
+// ReceiptService issues and verifies signed deletion receipts
+type ReceiptService struct {
+	accountService *AccountService
+	signer         signing.Signer
+}
+
+// NewReceiptService creates a new receipt service
+func NewReceiptService(accountService *AccountService, signer signing.Signer) *ReceiptService {
+	return &ReceiptService{
+		accountService: accountService,
+		signer:         signer,
+	}
+}
+
+// GetReceipt builds the signed receipt for a deletion's audit entry. Receipts
+// are derived only from the audit entry, so the same receipt is produced
+// every time it is requested.
+func (s *ReceiptService) GetReceipt(ctx context.Context, auditID int64) (*models.DeletionReceipt, error) {
+	log, err := s.accountService.GetAuditLog(ctx, auditID)
+	if err != nil {
+		return nil, err
+	}
+	if log.Action != models.AuditActionAccountDeletion {
+		return nil, ErrNotADeletion
+	}
+
+	receipt, err := buildReceipt(log)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt.Reference = receiptReference(log.ID, receiptPayload(receipt, log.RowHash))
+	receipt.Algorithm = s.signer.Algorithm()
+	receipt.KeyID = s.signer.KeyID()
+	receipt.Signature = s.signer.Sign(receiptPayload(receipt, log.RowHash))
+	receipt.GeneratedAt = time.Now().UTC()
+
+	return receipt, nil
+}
+
+// VerifyReceipt checks a receipt reference and signature against the audit
+// log. Unknown or malformed references are reported as invalid rather than
+// as errors so the public endpoint does not reveal which references exist.
+func (s *ReceiptService) VerifyReceipt(ctx context.Context, reference, signature string) (*models.ReceiptVerification, error) {
+	result := &models.ReceiptVerification{Reference: reference}
+
+	auditID, ok := parseReceiptReference(reference)
+	if !ok {
+		return result, nil
+	}
+
+	receipt, err := s.GetReceipt(ctx, auditID)
+	if errors.Is(err, ErrAuditLogNotFound) || errors.Is(err, ErrNotADeletion) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The reference must match too, or a valid signature could be replayed
+	// against another entry's ID
+	if receipt.Reference != reference || subtle.ConstantTimeCompare([]byte(receipt.Signature), []byte(signature)) != 1 {
+		return result, nil
+	}
+
+	result.Valid = true
+	result.CustomerEmail = maskEmail(receipt.CustomerEmail)
+	result.DeletedAt = &receipt.DeletedAt
+	result.TotalGroups = receipt.TotalGroups
+	result.TotalCompanies = receipt.TotalCompanies
+	result.TotalLocations = receipt.TotalLocations
+	return result, nil
+}
+
+// buildReceipt summarises the deleted groups and their contents from the
+// entities recorded with the audit entry
+func buildReceipt(log *models.AuditLog) (*models.DeletionReceipt, error) {
+	receipt := &models.DeletionReceipt{
+		AuditID:        log.ID,
+		CustomerEmail:  log.TargetEmail,
+		CustomerID:     log.TargetUserID,
+		DeletedAt:      log.CreatedAt,
+		Groups:         make([]models.ReceiptGroup, 0),
+		TotalGroups:    log.DeletedGroups,
+		TotalCompanies: log.DeletedCompanies,
+		TotalLocations: log.DeletedLocations,
+	}
+
+	groupIndex := make(map[string]int)
+	companyGroup := make(map[string]string)
+
+	// Entities are recorded bottom-up (locations, company, ..., group), so
+	// collect groups and company ownership first
+	for _, entity := range log.Entities {
+		switch entity.EntityType {
+		case models.EntityTypeGroup:
+			var state struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(entity.PreviousState, &state); err != nil {
+				return nil, fmt.Errorf("invalid previous state for group %s: %w", entity.EntityID, err)
+			}
+			groupIndex[entity.EntityID] = len(receipt.Groups)
+			receipt.Groups = append(receipt.Groups, models.ReceiptGroup{ID: entity.EntityID, Name: state.Name})
+		case models.EntityTypeCompany:
+			companyGroup[entity.EntityID] = entity.ParentID
+		}
+	}
+
+	for _, entity := range log.Entities {
+		switch entity.EntityType {
+		case models.EntityTypeCompany:
+			if i, ok := groupIndex[entity.ParentID]; ok {
+				receipt.Groups[i].Companies++
+			}
+		case models.EntityTypeLocation:
+			if i, ok := groupIndex[companyGroup[entity.ParentID]]; ok {
+				receipt.Groups[i].Locations++
+			}
+		}
+	}
+
+	return receipt, nil
+}
+
+// receiptPayload is the canonical form of a receipt covered by its signature
+func receiptPayload(r *models.DeletionReceipt, rowHash string) []byte {
+	groups := make([]string, 0, len(r.Groups))
+	for _, group := range r.Groups {
+		groups = append(groups, fmt.Sprintf("%s=%s/%d/%d", group.ID, group.Name, group.Companies, group.Locations))
+	}
+
+	return []byte(strings.Join([]string{
+		"admin-deletion-dashboard deletion receipt v1",
+		"reference:" + r.Reference,
+		"audit_id:" + r.AuditID,
+		"customer_email:" + r.CustomerEmail,
+		"customer_user_id:" + r.CustomerID,
+		"deleted_at:" + r.DeletedAt.Format(auditTimestampLayout),
+		"groups:" + strings.Join(groups, ";"),
+		"total_groups:" + strconv.Itoa(r.TotalGroups),
+		"total_companies:" + strconv.Itoa(r.TotalCompanies),
+		"total_locations:" + strconv.Itoa(r.TotalLocations),
+		"audit_row_hash:" + rowHash,
+	}, "\n"))
+}
+
+// receiptReference derives a reference like DR-1042-9F86D081 from the audit
+// ID and a checksum of the receipt content
+func receiptReference(auditID string, payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return receiptReferencePrefix + auditID + "-" + strings.ToUpper(hex.EncodeToString(sum[:4]))
+}
+
+// parseReceiptReference extracts the audit ID from a receipt reference
+func parseReceiptReference(reference string) (int64, bool) {
+	rest, ok := strings.CutPrefix(reference, receiptReferencePrefix)
+	if !ok {
+		return 0, false
+	}
+	id, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	auditID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return auditID, true
+}
+
+// maskEmail hides most of the local part of an email address
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+	"go.appointy.com/admin-deletion-dashboard/internal/signing"
+)
+
+func TestParseReceiptReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		wantID    int64
+		wantOK    bool
+	}{
+		{"DR-1042-9F86D081", 1042, true},
+		{"DR-1-ABCDEF01", 1, true},
+		{"DR-1042", 0, false},
+		{"dr-1042-9F86D081", 0, false},
+		{"1042-9F86D081", 0, false},
+		{"DR-abc-9F86D081", 0, false},
+		{"DR--5-9F86D081", 0, false},
+		{"DR-99999999999999999999-9F86D081", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		id, ok := parseReceiptReference(tt.reference)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("parseReceiptReference(%q) = %d, %v, want %d, %v", tt.reference, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestReceiptReference(t *testing.T) {
+	reference := receiptReference("1042", []byte("payload"))
+	if id, ok := parseReceiptReference(reference); !ok || id != 1042 {
+		t.Fatalf("reference %q does not parse back to its audit ID", reference)
+	}
+	if reference == receiptReference("1042", []byte("other payload")) {
+		t.Error("reference does not depend on the receipt content")
+	}
+	if len(reference) != len("DR-1042-")+8 {
+		t.Errorf("reference %q should end in an 8 character checksum", reference)
+	}
+}
+
+func TestReceiptSignature(t *testing.T) {
+	signer := signing.NewHMACSigner([]byte("test-key"))
+	receipt := func() *models.DeletionReceipt {
+		return &models.DeletionReceipt{
+			Reference:      "DR-42-ABCDEF01",
+			AuditID:        "42",
+			CustomerEmail:  "owner@example.org",
+			CustomerID:     "u1",
+			DeletedAt:      time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC),
+			Groups:         []models.ReceiptGroup{{ID: "g1", Name: "Salon", Companies: 1, Locations: 2}},
+			TotalGroups:    1,
+			TotalCompanies: 1,
+			TotalLocations: 2,
+		}
+	}
+	const rowHash = "abc123"
+	signature := signer.Sign(receiptPayload(receipt(), rowHash))
+
+	tests := []struct {
+		name    string
+		mutate  func(*models.DeletionReceipt)
+		rowHash string
+		want    bool
+	}{
+		{name: "unchanged", rowHash: rowHash, want: true},
+		{name: "other audit row", rowHash: "def456"},
+		{name: "other audit ID", mutate: func(r *models.DeletionReceipt) { r.AuditID = "43" }, rowHash: rowHash},
+		{name: "other reference", mutate: func(r *models.DeletionReceipt) { r.Reference = "DR-42-00000000" }, rowHash: rowHash},
+		{name: "other email", mutate: func(r *models.DeletionReceipt) { r.CustomerEmail = "x@example.org" }, rowHash: rowHash},
+		{name: "other totals", mutate: func(r *models.DeletionReceipt) { r.TotalLocations = 3 }, rowHash: rowHash},
+		{name: "other group", mutate: func(r *models.DeletionReceipt) { r.Groups[0].Locations = 1 }, rowHash: rowHash},
+		{name: "other time", mutate: func(r *models.DeletionReceipt) { r.DeletedAt = r.DeletedAt.Add(time.Microsecond) }, rowHash: rowHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := receipt()
+			if tt.mutate != nil {
+				tt.mutate(r)
+			}
+			if got := signer.Verify(receiptPayload(r, tt.rowHash), signature); got != tt.want {
+				t.Errorf("signature valid = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReceipt(t *testing.T) {
+	state := func(name string) json.RawMessage {
+		return json.RawMessage(`{"name":"` + name + `"}`)
+	}
+	log := &models.AuditLog{
+		ID:               "42",
+		TargetEmail:      "owner@example.org",
+		TargetUserID:     "u1",
+		DeletedGroups:    2,
+		DeletedCompanies: 2,
+		DeletedLocations: 3,
+		Entities: []models.AuditEntity{
+			{EntityType: models.EntityTypeLocation, EntityID: "l1", ParentID: "c1"},
+			{EntityType: models.EntityTypeLocation, EntityID: "l2", ParentID: "c1"},
+			{EntityType: models.EntityTypeCompany, EntityID: "c1", ParentID: "g1"},
+			{EntityType: models.EntityTypeGroup, EntityID: "g1", PreviousState: state("Salon")},
+			{EntityType: models.EntityTypeLocation, EntityID: "l3", ParentID: "c2"},
+			{EntityType: models.EntityTypeCompany, EntityID: "c2", ParentID: "g2"},
+			{EntityType: models.EntityTypeGroup, EntityID: "g2", PreviousState: state("Spa")},
+		},
+	}
+
+	receipt, err := buildReceipt(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ReceiptGroup{
+		{ID: "g1", Name: "Salon", Companies: 1, Locations: 2},
+		{ID: "g2", Name: "Spa", Companies: 1, Locations: 1},
+	}
+	if !reflect.DeepEqual(receipt.Groups, want) {
+		t.Errorf("groups = %+v, want %+v", receipt.Groups, want)
+	}
+	if receipt.AuditID != "42" || receipt.CustomerEmail != "owner@example.org" || receipt.TotalLocations != 3 {
+		t.Errorf("receipt = %+v", receipt)
+	}
+
+	log.Entities = append(log.Entities, models.AuditEntity{EntityType: models.EntityTypeGroup, EntityID: "g3", PreviousState: json.RawMessage(`not json`)})
+	if _, err := buildReceipt(log); err == nil {
+		t.Error("expected an error for an invalid group state")
+	}
+}
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"owner@example.org", "o***@example.org"},
+		{"a@example.org", "a***@example.org"},
+		{"@example.org", "***"},
+		{"not-an-email", "***"},
+		{"", "***"},
+	}
+
+	for _, tt := range tests {
+		if got := maskEmail(tt.email); got != tt.want {
+			t.Errorf("maskEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+const (
+	sagaMaxAttempts  = 6
+	sagaBaseBackoff  = 30 * time.Second
+	sagaMaxBackoff   = 1 * time.Hour
+	sagaBatchSize    = 10
+	sagaPollInterval = 5 * time.Second
+)
+
+// Saga statuses
+const (
+	sagaRunning      = "running"
+	sagaCompleted    = "completed"
+	sagaCompensating = "compensating"
+	sagaCompensated  = "compensated"
+	sagaFailed       = "failed"
+)
+
+// Saga step statuses
+const (
+	stepPending            = "pending"
+	stepConfirmed          = "confirmed"
+	stepFailed             = "failed"
+	stepCompensated        = "compensated"
+	stepCompensationFailed = "compensation_failed"
+)
+
+var (
+	// ErrSagaNotFound is returned when no erasure saga exists for a deletion
+	ErrSagaNotFound = newError(KindNotFound, "saga_not_found", "erasure saga not found")
+
+	// ErrSagaNotFailed is returned when retrying a saga that has not failed
+	ErrSagaNotFailed = newError(KindPreconditionFailed, "saga_not_failed", "only a failed erasure saga can be retried")
+)
+
+// SagaParticipant erases a deleted account's data in one external service.
+// Both operations must be idempotent: they are retried until they succeed or
+// run out of attempts, and again when an operator retries a failed saga.
+type SagaParticipant interface {
+	Name() string
+
+	// Delete erases the account's data in the external service
+	Delete(ctx context.Context, req *models.ErasureRequest) error
+
+	// Compensate undoes a confirmed Delete when a later participant fails
+	Compensate(ctx context.Context, req *models.ErasureRequest) error
+}
+
+// SagaCoordinator runs erasure sagas across the registered participants, in
+// registration order, once a deletion has committed. It starts sagas as an
+// outbox EventSink on account.deletion.executed, so no committed deletion is
+// missed. If a participant fails permanently, the participants that already
+// confirmed are compensated in reverse order. A saga whose compensation also
+// fails is parked as failed for an operator to retry.
+type SagaCoordinator struct {
+	db           *sql.DB
+	participants []SagaParticipant
+	monitor      *JobMonitor
+}
+
+// NewSagaCoordinator creates a coordinator for the given participants
+func NewSagaCoordinator(db *sql.DB, participants ...SagaParticipant) *SagaCoordinator {
+	return &SagaCoordinator{
+		db:           db,
+		participants: participants,
+		monitor:      newJobMonitor("erasure saga coordinator", sagaPollInterval),
+	}
+}
+
+// Monitor returns the coordinator's job status
+func (c *SagaCoordinator) Monitor() *JobMonitor {
+	return c.monitor
+}
+
+func (c *SagaCoordinator) Name() string { return "saga" }
+
+// Publish starts a saga for an executed deletion. Starting the same deletion
+// twice is a no-op.
+func (c *SagaCoordinator) Publish(ctx context.Context, event *models.Event, payload []byte) error {
+	if event.Type != models.EventAccountDeletionExecuted || len(c.participants) == 0 {
+		return nil
+	}
+
+	var envelope struct {
+		Data models.DeletionEventData `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return fmt.Errorf("invalid deletion event: %w", err)
+	}
+
+	return c.Start(ctx, envelope.Data)
+}
+
+// Start creates a saga and its steps for a committed deletion
+func (c *SagaCoordinator) Start(ctx context.Context, deletion models.DeletionEventData) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return databaseError("start transaction", err)
+	}
+	defer tx.Rollback()
+
+	var sagaID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO admin_erasure_sagas (audit_id, user_id, email, group_ids)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (audit_id) DO NOTHING
+		RETURNING id
+	`, deletion.AuditID, deletion.UserID, deletion.Email, pq.Array(deletion.GroupIDs)).Scan(&sagaID)
+	if err == sql.ErrNoRows {
+		return nil // Already started
+	}
+	if err != nil {
+		return databaseError("create erasure saga", err)
+	}
+
+	for i, participant := range c.participants {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO admin_erasure_saga_steps (saga_id, participant, position)
+			VALUES ($1, $2, $3)
+		`, sagaID, participant.Name(), i)
+		if err != nil {
+			return databaseError(fmt.Sprintf("create saga step %s", participant.Name()), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return databaseError("commit transaction", err)
+	}
+	return nil
+}
+
+// Run advances due sagas until ctx is cancelled. On cancellation the saga
+// being advanced is finished and the rest of its batch is released.
+func (c *SagaCoordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(sagaPollInterval)
+	defer ticker.Stop()
+
+	c.monitor.start()
+	defer c.monitor.stop()
+	ctx = logging.With(ctx, logging.Job(c.monitor.name))
+
+	for {
+		err := c.advanceDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "erasure saga run failed", logging.Err(err))
+		}
+		c.monitor.record(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// advanceDue claims running or compensating sagas whose next attempt is due.
+// Claimed sagas are pushed back by a lease so other instances skip them.
+func (c *SagaCoordinator) advanceDue(ctx context.Context) error {
+	rows, err := c.db.QueryContext(ctx, `
+		UPDATE admin_erasure_sagas
+		SET next_attempt_at = NOW() + INTERVAL '5 minutes'
+		WHERE id IN (
+			SELECT id FROM admin_erasure_sagas
+			WHERE status IN ('running', 'compensating') AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, audit_id, user_id, email, group_ids, status
+	`, sagaBatchSize)
+	if err != nil {
+		return err
+	}
+
+	sagas := make([]models.ErasureSaga, 0, sagaBatchSize)
+	for rows.Next() {
+		var saga models.ErasureSaga
+		var groupIDs pq.StringArray
+		if err := rows.Scan(&saga.ID, &saga.AuditID, &saga.UserID, &saga.Email, &groupIDs, &saga.Status); err != nil {
+			rows.Close()
+			return err
+		}
+		saga.GroupIDs = []string(groupIDs)
+		sagas = append(sagas, saga)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// A saga in hand is advanced even if the coordinator is stopped meanwhile
+	work := context.WithoutCancel(ctx)
+	for i := range sagas {
+		if ctx.Err() != nil {
+			ids := make([]int64, 0, len(sagas)-i)
+			for _, rest := range sagas[i:] {
+				ids = append(ids, rest.ID)
+			}
+			c.release(work, ids)
+			return nil
+		}
+		if err := c.advance(work, &sagas[i]); err != nil {
+			slog.ErrorContext(ctx, "failed to advance erasure saga", slog.Int64("saga_id", sagas[i].ID), logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// release makes claimed sagas due again, so they are advanced right away
+// after a restart or by another instance rather than once the lease expires
+func (c *SagaCoordinator) release(ctx context.Context, ids []int64) {
+	_, err := c.db.ExecContext(ctx, `
+		UPDATE admin_erasure_sagas SET next_attempt_at = NOW()
+		WHERE id = ANY($1) AND status IN ('running', 'compensating')
+	`, pq.Array(ids))
+	if err != nil {
+		// Left claimed; they are picked up again once the lease expires
+		slog.ErrorContext(ctx, "failed to release erasure sagas", logging.Err(err))
+		return
+	}
+	slog.InfoContext(ctx, "released erasure sagas for restart", slog.Int("count", len(ids)))
+}
+
+// advance runs a saga's steps until it finishes or a step needs a retry
+func (c *SagaCoordinator) advance(ctx context.Context, saga *models.ErasureSaga) error {
+	steps, err := c.getSteps(ctx, saga.ID)
+	if err != nil {
+		return err
+	}
+
+	req := &models.ErasureRequest{
+		SagaID:   saga.ID,
+		AuditID:  saga.AuditID,
+		UserID:   saga.UserID,
+		Email:    saga.Email,
+		GroupIDs: saga.GroupIDs,
+	}
+
+	if saga.Status == sagaRunning {
+		for i := range steps {
+			step := &steps[i]
+			if step.Status == stepConfirmed {
+				continue
+			}
+
+			err := c.participantDelete(ctx, step.Participant, req)
+			if err == nil {
+				if err := c.updateStep(ctx, saga.ID, step.Participant, `status = 'confirmed', attempts = attempts + 1, last_error = '', confirmed_at = NOW()`); err != nil {
+					return err
+				}
+				step.Status = stepConfirmed
+				continue
+			}
+
+			step.Attempts++
+			if step.Attempts < sagaMaxAttempts {
+				if err := c.updateStep(ctx, saga.ID, step.Participant, `attempts = attempts + 1, last_error = $3`, err.Error()); err != nil {
+					return err
+				}
+				return c.retryLater(ctx, saga.ID, step.Attempts)
+			}
+
+			// Out of attempts: undo the participants that already confirmed
+			if err := c.updateStep(ctx, saga.ID, step.Participant, `status = 'failed', attempts = attempts + 1, last_error = $3`, err.Error()); err != nil {
+				return err
+			}
+			step.Status = stepFailed
+			if err := c.setStatus(ctx, saga.ID, sagaCompensating); err != nil {
+				return err
+			}
+			saga.Status = sagaCompensating
+			break
+		}
+
+		if saga.Status == sagaRunning {
+			return c.setStatus(ctx, saga.ID, sagaCompleted)
+		}
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := &steps[i]
+		if step.Status != stepConfirmed {
+			continue
+		}
+
+		err := c.participantCompensate(ctx, step.Participant, req)
+		if err == nil {
+			if err := c.updateStep(ctx, saga.ID, step.Participant, `status = 'compensated', compensation_attempts = compensation_attempts + 1, compensated_at = NOW()`); err != nil {
+				return err
+			}
+			continue
+		}
+
+		step.CompensationAttempts++
+		if step.CompensationAttempts < sagaMaxAttempts {
+			if err := c.updateStep(ctx, saga.ID, step.Participant, `compensation_attempts = compensation_attempts + 1, last_error = $3`, err.Error()); err != nil {
+				return err
+			}
+			return c.retryLater(ctx, saga.ID, step.CompensationAttempts)
+		}
+
+		// Compensation is stuck too; park the saga for an operator
+		if err := c.updateStep(ctx, saga.ID, step.Participant, `status = 'compensation_failed', compensation_attempts = compensation_attempts + 1, last_error = $3`, err.Error()); err != nil {
+			return err
+		}
+		slog.ErrorContext(ctx, "erasure saga compensation failed, retry it once the participant is fixed",
+			slog.Int64("saga_id", saga.ID),
+			logging.AuditID(saga.AuditID),
+			slog.String("participant", step.Participant),
+			logging.Err(err),
+		)
+		return c.setStatus(ctx, saga.ID, sagaFailed)
+	}
+
+	return c.setStatus(ctx, saga.ID, sagaCompensated)
+}
+
+// Retry resumes compensating a failed saga, with a fresh set of attempts for
+// the participants whose compensation failed. Participants already
+// compensated are not called again.
+func (c *SagaCoordinator) Retry(ctx context.Context, auditID int64) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return databaseError("start transaction", err)
+	}
+	defer tx.Rollback()
+
+	var sagaID int64
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, status FROM admin_erasure_sagas WHERE audit_id = $1 FOR UPDATE
+	`, auditID).Scan(&sagaID, &status)
+	if err == sql.ErrNoRows {
+		return ErrSagaNotFound
+	}
+	if err != nil {
+		return databaseError("get erasure saga", err)
+	}
+	if status != sagaFailed {
+		return ErrSagaNotFailed
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE admin_erasure_saga_steps
+		SET status = 'confirmed', compensation_attempts = 0, updated_at = NOW()
+		WHERE saga_id = $1 AND status = 'compensation_failed'
+	`, sagaID)
+	if err != nil {
+		return databaseError("reset saga steps", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE admin_erasure_sagas
+		SET status = 'compensating', next_attempt_at = NOW(), updated_at = NOW(), completed_at = NULL
+		WHERE id = $1
+	`, sagaID)
+	if err != nil {
+		return databaseError("resume erasure saga", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return databaseError("commit transaction", err)
+	}
+	return nil
+}
+
+// participantDelete calls Delete on a registered participant
+func (c *SagaCoordinator) participantDelete(ctx context.Context, name string, req *models.ErasureRequest) error {
+	participant := c.participant(name)
+	if participant == nil {
+		return fmt.Errorf("participant %q is not configured", name)
+	}
+	return participant.Delete(ctx, req)
+}
+
+// participantCompensate calls Compensate on a registered participant
+func (c *SagaCoordinator) participantCompensate(ctx context.Context, name string, req *models.ErasureRequest) error {
+	participant := c.participant(name)
+	if participant == nil {
+		return fmt.Errorf("participant %q is not configured", name)
+	}
+	return participant.Compensate(ctx, req)
+}
+
+// participant finds a registered participant by name
+func (c *SagaCoordinator) participant(name string) SagaParticipant {
+	for _, p := range c.participants {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// updateStep applies a SET clause to a step; $3 is the optional argument
+func (c *SagaCoordinator) updateStep(ctx context.Context, sagaID int64, participant, set string, args ...interface{}) error {
+	_, err := c.db.ExecContext(ctx, `
+		UPDATE admin_erasure_saga_steps
+		SET `+set+`, updated_at = NOW()
+		WHERE saga_id = $1 AND participant = $2
+	`, append([]interface{}{sagaID, participant}, args...)...)
+	return err
+}
+
+// setStatus moves a saga to a new status, due immediately
+func (c *SagaCoordinator) setStatus(ctx context.Context, sagaID int64, status string) error {
+	_, err := c.db.ExecContext(ctx, `
+		UPDATE admin_erasure_sagas
+		SET status = $2, next_attempt_at = NOW(), updated_at = NOW(),
+			completed_at = CASE WHEN $2 IN ('completed', 'compensated', 'failed') THEN NOW() ELSE NULL END
+		WHERE id = $1
+	`, sagaID, status)
+	return err
+}
+
+// retryLater schedules the saga's next attempt with exponential backoff
+func (c *SagaCoordinator) retryLater(ctx context.Context, sagaID int64, attempts int) error {
+	_, err := c.db.ExecContext(ctx, `
+		UPDATE admin_erasure_sagas
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id = $1
+	`, sagaID, int(retryBackoff(attempts, sagaBaseBackoff, sagaMaxBackoff).Seconds()))
+	return err
+}
+
+// GetSaga returns the erasure saga of a deletion with every step
+func (c *SagaCoordinator) GetSaga(ctx context.Context, auditID int64) (*models.ErasureSaga, error) {
+	saga, err := scanSaga(c.db.QueryRowContext(ctx, `
+		SELECT `+sagaColumns+`
+		FROM admin_erasure_sagas
+		WHERE audit_id = $1
+	`, auditID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSagaNotFound
+	}
+	if err != nil {
+		return nil, databaseError("get erasure saga", err)
+	}
+
+	saga.Steps, err = c.getSteps(ctx, saga.ID)
+	if err != nil {
+		return nil, databaseError("get saga steps", err)
+	}
+	return saga, nil
+}
+
+// ListSagas lists sagas, newest first, optionally filtered by status
+func (c *SagaCoordinator) ListSagas(ctx context.Context, status string, limit int) ([]models.ErasureSaga, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT `+sagaColumns+`
+		FROM admin_erasure_sagas
+		WHERE $1 = '' OR status = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, status, limit)
+	if err != nil {
+		return nil, databaseError("list erasure sagas", err)
+	}
+	defer rows.Close()
+
+	sagas := make([]models.ErasureSaga, 0)
+	for rows.Next() {
+		saga, err := scanSaga(rows)
+		if err != nil {
+			return nil, databaseError("list erasure sagas", err)
+		}
+		sagas = append(sagas, *saga)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("list erasure sagas", err)
+	}
+
+	return sagas, nil
+}
+
+// getSteps returns a saga's steps in run order
+func (c *SagaCoordinator) getSteps(ctx context.Context, sagaID int64) ([]models.ErasureStep, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT participant, position, status, attempts, compensation_attempts, last_error, confirmed_at, compensated_at, updated_at
+		FROM admin_erasure_saga_steps
+		WHERE saga_id = $1
+		ORDER BY position
+	`, sagaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := make([]models.ErasureStep, 0)
+	for rows.Next() {
+		var step models.ErasureStep
+		var confirmedAt, compensatedAt sql.NullTime
+		if err := rows.Scan(&step.Participant, &step.Position, &step.Status, &step.Attempts, &step.CompensationAttempts,
+			&step.LastError, &confirmedAt, &compensatedAt, &step.UpdatedAt); err != nil {
+			return nil, err
+		}
+		step.ConfirmedAt = nullTimePtr(confirmedAt)
+		step.CompensatedAt = nullTimePtr(compensatedAt)
+		steps = append(steps, step)
+	}
+
+	return steps, rows.Err()
+}
+
+// sagaColumns are the columns read by scanSaga
+const sagaColumns = `id, audit_id, user_id, email, group_ids, status, created_at, updated_at, completed_at`
+
+// scanSaga scans a row selected with sagaColumns
+func scanSaga(row rowScanner) (*models.ErasureSaga, error) {
+	var saga models.ErasureSaga
+	var groupIDs pq.StringArray
+	var completedAt sql.NullTime
+	if err := row.Scan(&saga.ID, &saga.AuditID, &saga.UserID, &saga.Email, &groupIDs, &saga.Status,
+		&saga.CreatedAt, &saga.UpdatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	saga.GroupIDs = []string(groupIDs)
+	saga.CompletedAt = nullTimePtr(completedAt)
+	return &saga, nil
+}
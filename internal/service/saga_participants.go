@@ -0,0 +1,103 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+// participantTimeout bounds each call to an HTTP saga participant
+const participantTimeout = 30 * time.Second
+
+// HTTPParticipant erases data in an external service over HTTP. Delete and
+// Compensate POST the erasure request to the URL with "action" set to
+// "delete" or "compensate"; any 2xx response confirms the action.
+type HTTPParticipant struct {
+	name   string
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewHTTPParticipant creates a participant calling url. Requests are signed
+// like webhooks (X-Erasure-Signature) when secret is set.
+func NewHTTPParticipant(name, url, secret string) *HTTPParticipant {
+	return &HTTPParticipant{
+		name:   name,
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: participantTimeout},
+	}
+}
+
+func (p *HTTPParticipant) Name() string { return p.name }
+
+func (p *HTTPParticipant) Delete(ctx context.Context, req *models.ErasureRequest) error {
+	return p.call(ctx, "delete", req)
+}
+
+func (p *HTTPParticipant) Compensate(ctx context.Context, req *models.ErasureRequest) error {
+	return p.call(ctx, "compensate", req)
+}
+
+// call POSTs the request. The Idempotency-Key is the same on every retry of
+// the same action so the participant can skip repeats.
+func (p *HTTPParticipant) call(ctx context.Context, action string, req *models.ErasureRequest) error {
+	body, err := json.Marshal(struct {
+		Action string `json:"action"`
+		*models.ErasureRequest
+	}{action, req})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "admin-deletion-dashboard-saga")
+	httpReq.Header.Set("Idempotency-Key", fmt.Sprintf("erasure-%d-%s-%s", req.SagaID, p.name, action))
+	httpReq.Header.Set("X-Erasure-Action", action)
+	if p.secret != "" {
+		httpReq.Header.Set("X-Erasure-Signature", SignWebhookPayload(p.secret, time.Now().Unix(), body))
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBytes))
+		return fmt.Errorf("%s returned %d: %s", p.name, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ParseSagaParticipants parses "name=url,name=url" into HTTP participants,
+// keeping their order
+func ParseSagaParticipants(specs []string, secret string) ([]SagaParticipant, error) {
+	participants := make([]SagaParticipant, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		name, url, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || !(strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")) {
+			return nil, fmt.Errorf("invalid saga participant %q, expected name=http(s)://url", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate saga participant %q", name)
+		}
+		seen[name] = true
+		participants = append(participants, NewHTTPParticipant(name, url, secret))
+	}
+	return participants, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+func TestHTTPParticipantActions(t *testing.T) {
+	type call struct {
+		action         string
+		idempotencyKey string
+	}
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Action string `json:"action"`
+			SagaID int64  `json:"saga_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.SagaID != 7 {
+			t.Errorf("body = %+v, err %v", body, err)
+		}
+		if r.Header.Get("X-Erasure-Action") != body.Action {
+			t.Errorf("X-Erasure-Action = %q, body action %q", r.Header.Get("X-Erasure-Action"), body.Action)
+		}
+		calls = append(calls, call{body.Action, r.Header.Get("Idempotency-Key")})
+	}))
+	defer server.Close()
+
+	participant := NewHTTPParticipant("files", server.URL, "")
+	req := &models.ErasureRequest{SagaID: 7, AuditID: 42}
+	if err := participant.Delete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if err := participant.Compensate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []call{
+		{"delete", "erasure-7-files-delete"},
+		{"compensate", "erasure-7-files-compensate"},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %+v, want %+v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d = %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestHTTPParticipantRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "storage offline", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := NewHTTPParticipant("files", server.URL, "").Compensate(context.Background(), &models.ErasureRequest{SagaID: 7})
+	if err == nil || err.Error() != "files returned 502: storage offline" {
+		t.Errorf("err = %v", err)
+	}
+}
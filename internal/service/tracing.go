@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of service methods. Span attributes hold counts
+// and IDs of records, never emails, names or reasons.
+var tracer = tracing.Tracer("internal/service")
+
+// endSpan ends a span, recording the kind and code of err but not its
+// message, which may name the account
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		code := "internal_error"
+		var serviceErr *Error
+		if errors.As(err, &serviceErr) {
+			code = serviceErr.Code
+		}
+		span.SetAttributes(
+			attribute.String("error.kind", KindOf(err).String()),
+			attribute.String("error.code", code),
+		)
+		span.SetStatus(codes.Error, code)
+	}
+	span.End()
+}
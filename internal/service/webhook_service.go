@@ -0,0 +1,616 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.appointy.com/admin-deletion-dashboard/internal/logging"
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+const (
+	webhookSecretPrefix  = "whsec_"
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = 30 * time.Second
+	webhookMaxBackoff    = 6 * time.Hour
+	webhookBatchSize     = 20
+	webhookPollInterval  = 5 * time.Second
+	webhookTimeout       = 10 * time.Second
+	webhookMaxErrorBytes = 1024
+)
+
+// Webhook delivery statuses
+const (
+	deliveryPending   = "pending"
+	deliveryDelivered = "delivered"
+	deliveryDead      = "dead"
+	deliveryCancelled = "cancelled"
+)
+
+var (
+	// ErrWebhookNotFound is returned for an unknown or disabled subscription
+	ErrWebhookNotFound = newError(KindNotFound, "webhook_not_found", "webhook subscription not found")
+
+	// ErrInvalidEventType is returned when subscribing to an unknown event type
+	ErrInvalidEventType = newError(KindValidation, "invalid_event_type", "invalid event type")
+
+	// ErrInvalidWebhookURL is returned when a subscription URL is not an absolute http(s) URL
+	ErrInvalidWebhookURL = newError(KindValidation, "invalid_webhook_url", "webhook url must be an absolute http or https url")
+
+	// ErrInvalidWebhookSignature is returned when a delivery's signature does not verify
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// WebhookService manages webhook subscriptions and delivers events to them
+type WebhookService struct {
+	db         *sql.DB
+	client     *http.Client
+	dispatcher *JobMonitor
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(db *sql.DB) *WebhookService {
+	return &WebhookService{
+		db:         db,
+		client:     &http.Client{Timeout: webhookTimeout},
+		dispatcher: newJobMonitor("webhook dispatcher", webhookPollInterval),
+	}
+}
+
+// DispatcherMonitor returns the delivery dispatcher's job status
+func (s *WebhookService) DispatcherMonitor() *JobMonitor {
+	return s.dispatcher
+}
+
+// ValidateEventTypes checks that every requested event type exists
+func ValidateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		known := false
+		for _, t := range models.EventTypes {
+			if eventType == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrInvalidEventType.withMessage("invalid event type %q, must be one of %s", eventType, strings.Join(models.EventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that a subscription URL is an absolute http or
+// https URL
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// CreateSubscription stores a new subscription, generating its secret if
+// none was given
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error) {
+	if err := ValidateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := ValidateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = webhookSecretPrefix + hex.EncodeToString(b)
+	}
+
+	resp := &models.CreateWebhookResponse{
+		WebhookSubscription: models.WebhookSubscription{
+			URL:            req.URL,
+			EventTypes:     req.EventTypes,
+			Description:    req.Description,
+			CreatedByEmail: req.CreatedBy,
+		},
+		Secret: secret,
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO admin_webhook_subscriptions (url, secret, event_types, description, created_by_email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, req.URL, secret, pq.Array(req.EventTypes), req.Description, req.CreatedBy).Scan(&resp.ID, &resp.CreatedAt)
+	if err != nil {
+		return nil, databaseError("create webhook subscription", err)
+	}
+
+	return resp, nil
+}
+
+// ListSubscriptions lists all subscriptions without their secrets
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, url, event_types, description, created_by_email, created_at, disabled_at
+		FROM admin_webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, databaseError("list webhook subscriptions", err)
+	}
+	defer rows.Close()
+
+	subscriptions := make([]models.WebhookSubscription, 0)
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		var eventTypes pq.StringArray
+		var disabledAt sql.NullTime
+		if err := rows.Scan(&sub.ID, &sub.URL, &eventTypes, &sub.Description, &sub.CreatedByEmail, &sub.CreatedAt, &disabledAt); err != nil {
+			return nil, databaseError("list webhook subscriptions", err)
+		}
+		sub.EventTypes = []string(eventTypes)
+		sub.DisabledAt = nullTimePtr(disabledAt)
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("list webhook subscriptions", err)
+	}
+
+	return subscriptions, nil
+}
+
+// DisableSubscription stops new events going to a subscription. Its pending
+// deliveries are cancelled.
+func (s *WebhookService) DisableSubscription(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE admin_webhook_subscriptions
+		SET disabled_at = NOW()
+		WHERE id = $1 AND disabled_at IS NULL
+	`, id)
+	if err != nil {
+		return databaseError("disable webhook subscription", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return databaseError("disable webhook subscription", err)
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Emit queues an event for every active subscription to its type. Delivery
+// happens asynchronously in RunDispatcher.
+func (s *WebhookService) Emit(ctx context.Context, event *models.Event) error {
+	if event.ID == "" {
+		id, err := NewEventID()
+		if err != nil {
+			return err
+		}
+		event.ID = id
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO admin_webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3
+		FROM admin_webhook_subscriptions
+		WHERE disabled_at IS NULL AND $2 = ANY(event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`, event.ID, event.Type, payload)
+	if err != nil {
+		return databaseError(fmt.Sprintf("queue %s event", event.Type), err)
+	}
+
+	return nil
+}
+
+// SendTestEvent queues a ping event for a single subscription
+func (s *WebhookService) SendTestEvent(ctx context.Context, id int64, actor string) (*models.Event, error) {
+	eventID, err := NewEventID()
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.Event{
+		ID:         eventID,
+		Type:       models.EventWebhookPing,
+		OccurredAt: time.Now().UTC(),
+		Actor:      actor,
+		Data:       map[string]interface{}{"subscription_id": id},
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO admin_webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $2, $3, $4
+		FROM admin_webhook_subscriptions
+		WHERE id = $1 AND disabled_at IS NULL
+	`, id, event.ID, event.Type, payload)
+	if err != nil {
+		return nil, databaseError("queue test event", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, databaseError("queue test event", err)
+	}
+	if affected == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	return event, nil
+}
+
+// ListDeliveries returns a subscription's delivery log, newest first,
+// optionally filtered by status
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+			last_status_code, last_error, created_at, delivered_at
+		FROM admin_webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, subscriptionID, status, limit)
+	if err != nil {
+		return nil, databaseError("list webhook deliveries", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, databaseError("list webhook deliveries", err)
+		}
+		d.Payload = json.RawMessage(payload)
+		d.DeliveredAt = nullTimePtr(deliveredAt)
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("list webhook deliveries", err)
+	}
+
+	return deliveries, nil
+}
+
+// ListDeadLetters returns deliveries that exhausted their retries, newest first
+func (s *WebhookService) ListDeadLetters(ctx context.Context, limit int) ([]models.WebhookDeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, delivery_id, subscription_id, event_id, event_type, payload, attempts, last_error, created_at
+		FROM admin_webhook_dead_letters
+		ORDER BY id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, databaseError("list webhook dead letters", err)
+	}
+	defer rows.Close()
+
+	letters := make([]models.WebhookDeadLetter, 0)
+	for rows.Next() {
+		var l models.WebhookDeadLetter
+		var payload []byte
+		if err := rows.Scan(&l.ID, &l.DeliveryID, &l.SubscriptionID, &l.EventID, &l.EventType, &payload, &l.Attempts, &l.LastError, &l.CreatedAt); err != nil {
+			return nil, databaseError("list webhook dead letters", err)
+		}
+		l.Payload = json.RawMessage(payload)
+		letters = append(letters, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, databaseError("list webhook dead letters", err)
+	}
+
+	return letters, nil
+}
+
+// RunDispatcher delivers due webhook deliveries until ctx is cancelled. On
+// cancellation the delivery being sent is finished and the rest of its batch
+// is released.
+func (s *WebhookService) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	s.dispatcher.start()
+	defer s.dispatcher.stop()
+	ctx = logging.With(ctx, logging.Job(s.dispatcher.name))
+
+	for {
+		for ctx.Err() == nil {
+			n, err := s.dispatchDue(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "webhook dispatch failed", logging.Err(err))
+			}
+			// Keep draining while full batches come back
+			if err != nil || n < webhookBatchSize {
+				s.dispatcher.record(err)
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pendingDelivery is a claimed delivery with its subscription's endpoint
+type pendingDelivery struct {
+	id        int64
+	eventID   string
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+	disabled  bool
+}
+
+// dispatchDue claims a batch of due deliveries and attempts each one. Claimed
+// rows are pushed back by a lease so other instances skip them meanwhile.
+func (s *WebhookService) dispatchDue(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE admin_webhook_deliveries d
+		SET next_attempt_at = NOW() + INTERVAL '2 minutes'
+		FROM admin_webhook_subscriptions s
+		WHERE d.subscription_id = s.id AND d.id IN (
+			SELECT id FROM admin_webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret, s.disabled_at IS NOT NULL
+	`, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := make([]pendingDelivery, 0, webhookBatchSize)
+	for rows.Next() {
+		var d pendingDelivery
+		if err := rows.Scan(&d.id, &d.eventID, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret, &d.disabled); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// A delivery in hand is sent even if the dispatcher is stopped meanwhile
+	work := context.WithoutCancel(ctx)
+	for i, d := range batch {
+		if ctx.Err() != nil {
+			ids := make([]int64, 0, len(batch)-i)
+			for _, rest := range batch[i:] {
+				ids = append(ids, rest.id)
+			}
+			s.release(work, ids)
+			return i, nil
+		}
+		if err := s.attempt(work, d); err != nil {
+			slog.ErrorContext(ctx, "failed to record webhook delivery", slog.Int64("delivery_id", d.id), logging.Err(err))
+		}
+	}
+
+	return len(batch), nil
+}
+
+// release makes claimed deliveries due again, so they are sent right away
+// after a restart or by another instance rather than once the lease expires
+func (s *WebhookService) release(ctx context.Context, ids []int64) {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE admin_webhook_deliveries SET next_attempt_at = NOW()
+		WHERE id = ANY($1) AND status = 'pending'
+	`, pq.Array(ids))
+	if err != nil {
+		// Left claimed; they are picked up again once the lease expires
+		slog.ErrorContext(ctx, "failed to release webhook deliveries", logging.Err(err))
+		return
+	}
+	slog.InfoContext(ctx, "released webhook deliveries for restart", slog.Int("count", len(ids)))
+}
+
+// attempt sends one delivery and records the outcome, scheduling a retry
+// with exponential backoff or dead-lettering it after the last attempt
+func (s *WebhookService) attempt(ctx context.Context, d pendingDelivery) error {
+	if d.disabled {
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE admin_webhook_deliveries SET status = $2, last_error = 'subscription disabled' WHERE id = $1
+		`, d.id, deliveryCancelled)
+		return err
+	}
+
+	statusCode, sendErr := s.send(ctx, d)
+	attempts := d.attempts + 1
+
+	if sendErr == nil {
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE admin_webhook_deliveries
+			SET status = $2, attempts = $3, last_status_code = $4, last_error = '', delivered_at = NOW()
+			WHERE id = $1
+		`, d.id, deliveryDelivered, attempts, statusCode)
+		return err
+	}
+
+	if attempts < webhookMaxAttempts {
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE admin_webhook_deliveries
+			SET attempts = $2, last_status_code = $3, last_error = $4, next_attempt_at = NOW() + $5 * INTERVAL '1 second'
+			WHERE id = $1
+		`, d.id, attempts, statusCode, sendErr.Error(), int(retryBackoff(attempts, webhookBaseBackoff, webhookMaxBackoff).Seconds()))
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE admin_webhook_deliveries
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = $5
+		WHERE id = $1
+	`, d.id, deliveryDead, attempts, statusCode, sendErr.Error())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO admin_webhook_dead_letters
+		(delivery_id, subscription_id, event_id, event_type, payload, attempts, last_error)
+		SELECT id, subscription_id, event_id, event_type, payload, attempts, last_error
+		FROM admin_webhook_deliveries
+		WHERE id = $1
+	`, d.id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// send POSTs the signed payload; any non-2xx response is a failure
+func (s *WebhookService) send(ctx context.Context, d pendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "admin-deletion-dashboard-webhooks")
+	req.Header.Set("X-Webhook-ID", d.eventID)
+	req.Header.Set("X-Webhook-Event", d.eventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.id, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(d.secret, timestamp, d.payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorBytes))
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryBackoff returns the delay before the next attempt, doubling from base
+// after each failed attempt (base, 2*base, 4*base, ...) up to max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+	if attempts > 30 {
+		return max
+	}
+	delay := base << (attempts - 1)
+	if delay <= 0 || delay > max {
+		return max
+	}
+	return delay
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature header value:
+// t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>">
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// VerifyWebhookSignature checks an X-Webhook-Signature header, rejecting
+// timestamps older than tolerance to prevent replays
+func VerifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	var timestamp int64
+	var signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == 0 || signature == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidWebhookSignature)
+	}
+
+	expected := SignWebhookPayload(secret, timestamp, payload)
+	if !hmac.Equal([]byte(expected), []byte(fmt.Sprintf("t=%d,v1=%s", timestamp, signature))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// NewEventID generates a random event ID used by receivers for deduplication
+func NewEventID() (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
+	}
+	return "evt_" + id, nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
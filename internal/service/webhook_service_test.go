@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.appointy.com/admin-deletion-dashboard/internal/models"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	base, max := 30*time.Second, time.Hour
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{-1, base},
+		{0, base},
+		{1, base},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, max},
+		{31, max},
+		{64, max},
+		{1000, max},
+	}
+
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts, base, max); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name    string
+		header  string
+		secret  string
+		payload []byte
+		wantErr bool
+	}{
+		{name: "valid", header: SignWebhookPayload(secret, now, payload)},
+		{name: "within skew", header: SignWebhookPayload(secret, now-240, payload)},
+		{name: "clock ahead within skew", header: SignWebhookPayload(secret, now+240, payload)},
+		{name: "too old", header: SignWebhookPayload(secret, now-600, payload), wantErr: true},
+		{name: "too far ahead", header: SignWebhookPayload(secret, now+600, payload), wantErr: true},
+		{name: "other secret", header: SignWebhookPayload("whsec_other", now, payload), wantErr: true},
+		{name: "tampered payload", header: SignWebhookPayload(secret, now, payload), payload: []byte(`{"id":"evt_2"}`), wantErr: true},
+		{name: "bad signature", header: "t=" + strconv.FormatInt(now, 10) + ",v1=00ff", wantErr: true},
+		{name: "replayed with new timestamp", header: "t=" + strconv.FormatInt(now, 10) + ",v1=" + signaturePart(SignWebhookPayload(secret, now-600, payload)), wantErr: true},
+		{name: "missing timestamp", header: "v1=" + signaturePart(SignWebhookPayload(secret, now, payload)), wantErr: true},
+		{name: "missing signature", header: "t=" + strconv.FormatInt(now, 10), wantErr: true},
+		{name: "empty", header: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, p := secret, payload
+			if tt.secret != "" {
+				s = tt.secret
+			}
+			if tt.payload != nil {
+				p = tt.payload
+			}
+
+			err := VerifyWebhookSignature(s, tt.header, p, 5*time.Minute)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWebhookSignature) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidWebhookSignature)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	header := SignWebhookPayload("secret", 1700000000, []byte("body"))
+	if !regexp.MustCompile(`^t=1700000000,v1=[0-9a-f]{64}$`).MatchString(header) {
+		t.Errorf("header = %q", header)
+	}
+	if header != SignWebhookPayload("secret", 1700000000, []byte("body")) {
+		t.Error("signature is not deterministic")
+	}
+	if header == SignWebhookPayload("secret", 1700000001, []byte("body")) {
+		t.Error("signature does not cover the timestamp")
+	}
+}
+
+func TestValidateEventTypes(t *testing.T) {
+	if err := ValidateEventTypes(models.EventTypes); err != nil {
+		t.Errorf("known event types rejected: %v", err)
+	}
+	if err := ValidateEventTypes(nil); err != nil {
+		t.Errorf("no event types rejected: %v", err)
+	}
+	if err := ValidateEventTypes([]string{models.EventAccountDeletionExecuted, "account.deletion.approved"}); KindOf(err) != KindValidation {
+		t.Errorf("err = %v, want a validation error", err)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://hooks.example.com/deletions", true},
+		{"http://localhost:9000/hook?token=x", true},
+		{"ftp://hooks.example.com/deletions", false},
+		{"javascript:alert(1)", false},
+		{"/relative/path", false},
+		{"hooks.example.com/deletions", false},
+		{"https://", false},
+		{"https://[::1/bad", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateWebhookURL(tt.url)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want nil", tt.url, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want ErrInvalidWebhookURL", tt.url, err)
+		}
+	}
+}
+
+// signaturePart returns the v1 value of a signature header
+func signaturePart(header string) string {
+	return regexp.MustCompile(`v1=([0-9a-f]+)`).FindStringSubmatch(header)[1]
+}
@@ -0,0 +1,32 @@
+-- Migration: Create API keys table for service accounts
+-- Created: 2026-10-18
+
+-- Create the API keys table (only a SHA-256 hash of each key is stored)
+CREATE TABLE IF NOT EXISTS admin_api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit_per_minute INTEGER NOT NULL DEFAULT 0,
+    created_by_email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by_email VARCHAR(255) DEFAULT ''
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_hash ON admin_api_keys(key_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_active_name ON admin_api_keys(name) WHERE revoked_at IS NULL;
+
+-- Record whether each audited action was performed by a user or an API key
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS actor_type VARCHAR(20) NOT NULL DEFAULT 'user';
+
+-- Add comment to table
+COMMENT ON TABLE admin_api_keys IS 'Service account API keys for automated deletion pipelines';
+COMMENT ON COLUMN admin_api_keys.key_prefix IS 'Non-secret prefix of the key, shown to identify it';
+COMMENT ON COLUMN admin_api_keys.key_hash IS 'Hex SHA-256 of the full key';
+COMMENT ON COLUMN admin_api_keys.scopes IS 'Routes the key may call (account:lookup, account:delete, audit:read)';
+COMMENT ON COLUMN admin_api_keys.rate_limit_per_minute IS 'Maximum requests per minute, 0 for unlimited';
+COMMENT ON COLUMN admin_deletion_audit_log.actor_type IS 'Type of actor in deleted_by_email: user or api_key';
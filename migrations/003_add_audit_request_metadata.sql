@@ -0,0 +1,17 @@
+-- Migration: Record request metadata with each audit log entry
+-- Created: 2026-10-18
+
+-- ip_address already exists from 001; add the remaining request details
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS user_agent TEXT DEFAULT '';
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS request_id VARCHAR(128) DEFAULT '';
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS session_id VARCHAR(128) DEFAULT '';
+
+-- Create index for tracing a request or session across entries
+CREATE INDEX IF NOT EXISTS idx_audit_request_id ON admin_deletion_audit_log(request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_session_id ON admin_deletion_audit_log(session_id);
+
+-- Add comments
+COMMENT ON COLUMN admin_deletion_audit_log.ip_address IS 'Client IP address, resolved through trusted proxies';
+COMMENT ON COLUMN admin_deletion_audit_log.user_agent IS 'User agent of the client that performed the action';
+COMMENT ON COLUMN admin_deletion_audit_log.request_id IS 'Request ID (X-Request-ID) of the HTTP request';
+COMMENT ON COLUMN admin_deletion_audit_log.session_id IS 'Login session (JWT ID) or API key that performed the action';
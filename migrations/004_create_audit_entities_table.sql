@@ -0,0 +1,22 @@
+-- Migration: Record every entity affected by an audited deletion
+-- Created: 2026-10-18
+
+-- Create the audit entities table
+CREATE TABLE IF NOT EXISTS admin_deletion_audit_entities (
+    id BIGSERIAL PRIMARY KEY,
+    audit_id INTEGER NOT NULL REFERENCES admin_deletion_audit_log(id),
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id TEXT NOT NULL,
+    parent_id TEXT NOT NULL DEFAULT '',
+    previous_state JSONB NOT NULL DEFAULT '{}'
+);
+
+-- Create indexes for lookups by audit entry and by entity
+CREATE INDEX IF NOT EXISTS idx_audit_entities_audit_id ON admin_deletion_audit_entities(audit_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entities_entity ON admin_deletion_audit_entities(entity_type, entity_id);
+
+-- Add comment to table
+COMMENT ON TABLE admin_deletion_audit_entities IS 'Users, groups, companies and locations affected by each audited deletion';
+COMMENT ON COLUMN admin_deletion_audit_entities.entity_type IS 'One of user, group, company, location';
+COMMENT ON COLUMN admin_deletion_audit_entities.parent_id IS 'Owning entity: company for locations, group for companies, user for groups';
+COMMENT ON COLUMN admin_deletion_audit_entities.previous_state IS 'Row state (name, parent, is_deleted, deleted_by, deleted_on) before the soft delete';
@@ -0,0 +1,11 @@
+-- Migration: Make the audit log tamper-evident with a hash chain
+-- Created: 2026-10-18
+
+-- Each entry stores the previous entry's hash and the hash of its own
+-- content (including affected entities) chained to it
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS prev_hash CHAR(64);
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS row_hash CHAR(64);
+
+-- Add comments
+COMMENT ON COLUMN admin_deletion_audit_log.prev_hash IS 'row_hash of the preceding chained entry (empty for the first)';
+COMMENT ON COLUMN admin_deletion_audit_log.row_hash IS 'SHA-256 of prev_hash and the canonical entry content; NULL for entries written before chaining';
@@ -0,0 +1,13 @@
+-- Migration: Indexes for audit log search and keyset pagination
+-- Created: 2026-10-18
+
+-- Keyset pagination orders by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_audit_created_at_id ON admin_deletion_audit_log(created_at DESC, id DESC);
+
+-- Email filters are case-insensitive
+CREATE INDEX IF NOT EXISTS idx_audit_deleted_by_lower ON admin_deletion_audit_log(LOWER(deleted_by_email));
+CREATE INDEX IF NOT EXISTS idx_audit_target_email_lower ON admin_deletion_audit_log(LOWER(target_email));
+
+-- Group and action filters
+CREATE INDEX IF NOT EXISTS idx_audit_group_ids ON admin_deletion_audit_log USING GIN (group_ids);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON admin_deletion_audit_log(action);
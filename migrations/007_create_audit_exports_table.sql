@@ -0,0 +1,25 @@
+-- Migration: Signed manifests for audit log exports
+-- Created: 2026-10-18
+
+-- Create the audit exports table
+CREATE TABLE IF NOT EXISTS admin_deletion_audit_exports (
+    id VARCHAR(32) PRIMARY KEY,
+    format VARCHAR(10) NOT NULL,
+    filter TEXT NOT NULL DEFAULT '',
+    row_count INTEGER NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    algorithm VARCHAR(20) NOT NULL,
+    key_id VARCHAR(32) NOT NULL,
+    signature TEXT NOT NULL
+);
+
+-- Create index for listing exports by requester
+CREATE INDEX IF NOT EXISTS idx_audit_exports_created_by ON admin_deletion_audit_exports(created_by);
+
+-- Add comment to table
+COMMENT ON TABLE admin_deletion_audit_exports IS 'Manifest of every audit log export so exported files can be verified later';
+COMMENT ON COLUMN admin_deletion_audit_exports.filter IS 'Query string the export was filtered by';
+COMMENT ON COLUMN admin_deletion_audit_exports.sha256 IS 'Hex SHA-256 of the exported file as streamed';
+COMMENT ON COLUMN admin_deletion_audit_exports.signature IS 'Signature over the canonical manifest, see README';
@@ -0,0 +1,10 @@
+-- Migration: Record account lookups and audit log views
+-- Created: 2026-10-18
+
+-- What was searched for, on ACCOUNT_LOOKUP and AUDIT_LOG_VIEW entries
+ALTER TABLE admin_deletion_audit_log ADD COLUMN IF NOT EXISTS query TEXT NOT NULL DEFAULT '';
+
+-- Add comments
+COMMENT ON COLUMN admin_deletion_audit_log.action IS 'ACCOUNT_DELETION, ACCOUNT_LOOKUP or AUDIT_LOG_VIEW';
+COMMENT ON COLUMN admin_deletion_audit_log.deleted_by_email IS 'Actor who performed the action (deletion, lookup or view)';
+COMMENT ON COLUMN admin_deletion_audit_log.query IS 'Lookup email or audit log query string for access entries';
@@ -0,0 +1,5 @@
+-- Revert: Outbound webhooks for deletion lifecycle events
+
+DROP TABLE IF EXISTS admin_webhook_dead_letters;
+DROP TABLE IF EXISTS admin_webhook_deliveries;
+DROP TABLE IF EXISTS admin_webhook_subscriptions;
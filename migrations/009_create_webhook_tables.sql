@@ -0,0 +1,56 @@
+-- Migration: Outbound webhooks for deletion lifecycle events
+-- Created: 2026-10-18
+
+-- Create the webhook subscriptions table
+CREATE TABLE IF NOT EXISTS admin_webhook_subscriptions (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by_email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled_at TIMESTAMP
+);
+
+-- Create the webhook deliveries table (one row per event per subscription)
+CREATE TABLE IF NOT EXISTS admin_webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES admin_webhook_subscriptions(id),
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+-- Create the dead-letter table for deliveries that exhausted their retries
+CREATE TABLE IF NOT EXISTS admin_webhook_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES admin_webhook_deliveries(id),
+    subscription_id INTEGER NOT NULL REFERENCES admin_webhook_subscriptions(id),
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON admin_webhook_deliveries(subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON admin_webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_subscription ON admin_webhook_dead_letters(subscription_id);
+
+-- Add comments
+COMMENT ON TABLE admin_webhook_subscriptions IS 'Downstream systems notified of deletion lifecycle events';
+COMMENT ON COLUMN admin_webhook_subscriptions.secret IS 'Shared secret for the X-Webhook-Signature HMAC';
+COMMENT ON COLUMN admin_webhook_subscriptions.event_types IS 'Subscribed event types, e.g. account.deletion.executed';
+COMMENT ON TABLE admin_webhook_deliveries IS 'Delivery log: one row per event per subscription with retry state';
+COMMENT ON COLUMN admin_webhook_deliveries.status IS 'pending, delivered, dead (retries exhausted) or cancelled (subscription disabled)';
+COMMENT ON TABLE admin_webhook_dead_letters IS 'Deliveries that failed after the maximum number of attempts';
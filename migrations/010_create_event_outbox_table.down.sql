@@ -0,0 +1,3 @@
+-- Revert: Transactional outbox for deletion lifecycle events
+
+DROP TABLE IF EXISTS admin_event_outbox;
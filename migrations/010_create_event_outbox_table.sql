@@ -0,0 +1,23 @@
+-- Migration: Transactional outbox for deletion lifecycle events
+-- Created: 2026-10-18
+
+-- Create the outbox table (rows are written in the same transaction as the change they announce)
+CREATE TABLE IF NOT EXISTS admin_event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+-- Create index for the relay's unpublished scan
+CREATE INDEX IF NOT EXISTS idx_event_outbox_unpublished ON admin_event_outbox(next_attempt_at) WHERE published_at IS NULL;
+
+-- Add comments
+COMMENT ON TABLE admin_event_outbox IS 'Events awaiting publication to sinks (webhook, log, NATS) by the outbox relay';
+COMMENT ON COLUMN admin_event_outbox.event_id IS 'Deduplication ID sent to every sink; stable across redeliveries';
+COMMENT ON COLUMN admin_event_outbox.published_at IS 'When every sink accepted the event; NULL while pending';
@@ -0,0 +1,4 @@
+-- Revert: Cross-service erasure sagas
+
+DROP TABLE IF EXISTS admin_erasure_saga_steps;
+DROP TABLE IF EXISTS admin_erasure_sagas;
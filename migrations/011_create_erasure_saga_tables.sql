@@ -0,0 +1,42 @@
+-- Migration: Cross-service erasure sagas
+-- Created: 2026-10-18
+
+-- Create the sagas table (one per executed deletion)
+CREATE TABLE IF NOT EXISTS admin_erasure_sagas (
+    id BIGSERIAL PRIMARY KEY,
+    audit_id BIGINT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    group_ids TEXT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- Create the saga steps table (one per participant, run in position order)
+CREATE TABLE IF NOT EXISTS admin_erasure_saga_steps (
+    id BIGSERIAL PRIMARY KEY,
+    saga_id BIGINT NOT NULL REFERENCES admin_erasure_sagas(id),
+    participant VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL,
+    status VARCHAR(30) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    compensation_attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    confirmed_at TIMESTAMP,
+    compensated_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (saga_id, participant)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_erasure_sagas_due ON admin_erasure_sagas(next_attempt_at) WHERE status IN ('running', 'compensating');
+CREATE INDEX IF NOT EXISTS idx_erasure_sagas_status ON admin_erasure_sagas(status);
+
+-- Add comments
+COMMENT ON TABLE admin_erasure_sagas IS 'Erasure of a deleted account''s data in external services, orchestrated after the database deletion';
+COMMENT ON COLUMN admin_erasure_sagas.status IS 'running, completed, compensating, compensated or failed';
+COMMENT ON TABLE admin_erasure_saga_steps IS 'Per-participant state of an erasure saga';
+COMMENT ON COLUMN admin_erasure_saga_steps.status IS 'pending, confirmed, failed, compensated or compensation_failed';
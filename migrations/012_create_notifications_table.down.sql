@@ -0,0 +1,3 @@
+-- Revert: Deletion outcome notifications
+
+DROP TABLE IF EXISTS admin_notifications;
@@ -0,0 +1,21 @@
+-- Migration: Deletion outcome notifications
+-- Created: 2026-10-18
+
+-- Create the notifications table (one row per message sent, so relay
+-- retries do not notify the same recipient twice)
+CREATE TABLE IF NOT EXISTS admin_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, channel, recipient)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_notifications_sent_at ON admin_notifications(sent_at);
+
+-- Add comments
+COMMENT ON TABLE admin_notifications IS 'Notifications sent to operators, approvers and customers about deletion outcomes';
+COMMENT ON COLUMN admin_notifications.recipient IS 'Email address, or the channel name for chat rooms';
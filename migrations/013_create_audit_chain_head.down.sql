@@ -0,0 +1,3 @@
+-- Revert: Record where the audit hash chain starts and its head
+
+DROP TABLE IF EXISTS admin_audit_chain;
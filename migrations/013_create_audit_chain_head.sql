@@ -0,0 +1,31 @@
+-- Migration: Record where the audit hash chain starts and its head
+-- Created: 2026-10-18
+
+-- A single row holding the first entry that must be sealed and the hash and
+-- entry count of the newest sealed entry. Verification fails on any unsealed
+-- entry from start_id on and on a chain that ends before the recorded head.
+CREATE TABLE IF NOT EXISTS admin_audit_chain (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    start_id BIGINT NOT NULL,
+    head_id BIGINT,
+    head_hash TEXT NOT NULL DEFAULT '',
+    entry_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The chain starts at the first entry sealed since migration 005, or at the
+-- next entry when none has been sealed yet
+INSERT INTO admin_audit_chain (start_id, head_id, head_hash, entry_count)
+SELECT
+    COALESCE(
+        (SELECT MIN(id) FROM admin_deletion_audit_log WHERE row_hash IS NOT NULL),
+        (SELECT COALESCE(MAX(id), 0) + 1 FROM admin_deletion_audit_log)
+    ),
+    (SELECT MAX(id) FROM admin_deletion_audit_log WHERE row_hash IS NOT NULL),
+    COALESCE((SELECT row_hash FROM admin_deletion_audit_log WHERE row_hash IS NOT NULL ORDER BY id DESC LIMIT 1), ''),
+    (SELECT COUNT(*) FROM admin_deletion_audit_log WHERE row_hash IS NOT NULL)
+ON CONFLICT (singleton) DO NOTHING;
+
+-- Add comments
+COMMENT ON TABLE admin_audit_chain IS 'Start and head of the audit log hash chain, updated with every sealed entry';
+COMMENT ON COLUMN admin_audit_chain.start_id IS 'First audit log id that must carry a hash; earlier entries predate chaining';
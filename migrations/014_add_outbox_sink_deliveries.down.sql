@@ -0,0 +1,7 @@
+-- Revert: Record outbox delivery per sink and dead-letter undeliverable events
+
+DROP INDEX IF EXISTS idx_event_outbox_dead_lettered;
+DROP INDEX IF EXISTS idx_event_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_unpublished ON admin_event_outbox(next_attempt_at) WHERE published_at IS NULL;
+ALTER TABLE admin_event_outbox DROP COLUMN IF EXISTS dead_lettered_at;
+DROP TABLE IF EXISTS admin_event_outbox_deliveries;
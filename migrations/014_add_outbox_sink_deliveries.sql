@@ -0,0 +1,26 @@
+-- Migration: Record outbox delivery per sink and dead-letter undeliverable events
+-- Created: 2026-10-18
+
+-- One row per sink that accepted an event, so a retry only goes to the
+-- sinks that failed
+CREATE TABLE IF NOT EXISTS admin_event_outbox_deliveries (
+    outbox_id BIGINT NOT NULL REFERENCES admin_event_outbox(id) ON DELETE CASCADE,
+    sink VARCHAR(64) NOT NULL,
+    delivered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (outbox_id, sink)
+);
+
+-- Events still failing after the last attempt are dead-lettered instead of
+-- being retried forever
+ALTER TABLE admin_event_outbox ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP;
+
+-- Replace the relay's scan index so dead-lettered events drop out of it
+DROP INDEX IF EXISTS idx_event_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON admin_event_outbox(next_attempt_at)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_dead_lettered ON admin_event_outbox(dead_lettered_at)
+    WHERE dead_lettered_at IS NOT NULL;
+
+-- Add comments
+COMMENT ON TABLE admin_event_outbox_deliveries IS 'Sinks that accepted each outbox event';
+COMMENT ON COLUMN admin_event_outbox.dead_lettered_at IS 'When the relay gave up on the sinks named in last_error; NULL while pending or published';
@@ -0,0 +1,4 @@
+-- Revert: Park erasure sagas left compensating as failed
+
+-- Nothing to revert: which failed sagas were compensating is not recorded,
+-- and retrying them erases rather than restores
@@ -0,0 +1,13 @@
+-- Migration: Park erasure sagas left compensating as failed
+-- Created: 2026-10-18
+
+-- Sagas no longer compensate confirmed participants, since the database
+-- deletion stays committed. Sagas caught mid-compensation are parked as
+-- failed for an operator to retry.
+UPDATE admin_erasure_sagas
+SET status = 'failed', completed_at = NOW(), updated_at = NOW()
+WHERE status = 'compensating';
+
+-- Update comments
+COMMENT ON COLUMN admin_erasure_sagas.status IS 'running, completed or failed (compensating and compensated on sagas from older versions)';
+COMMENT ON COLUMN admin_erasure_saga_steps.status IS 'pending, confirmed or failed (compensated and compensation_failed on sagas from older versions)';
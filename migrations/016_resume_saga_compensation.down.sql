@@ -0,0 +1,4 @@
+-- Revert: Compensate erasure sagas again
+
+COMMENT ON COLUMN admin_erasure_sagas.status IS 'running, completed or failed (compensating and compensated on sagas from older versions)';
+COMMENT ON COLUMN admin_erasure_saga_steps.status IS 'pending, confirmed or failed (compensated and compensation_failed on sagas from older versions)';
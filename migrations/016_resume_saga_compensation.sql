@@ -0,0 +1,8 @@
+-- Migration: Compensate erasure sagas again
+-- Created: 2026-10-18
+
+-- Sagas compensate their confirmed participants when a later one fails.
+-- Sagas parked as failed by migration 015 resume compensating when an
+-- operator retries them.
+COMMENT ON COLUMN admin_erasure_sagas.status IS 'running, completed, compensating, compensated or failed';
+COMMENT ON COLUMN admin_erasure_saga_steps.status IS 'pending, confirmed, failed, compensated or compensation_failed';
@@ -0,0 +1,373 @@
+// Package migrations embeds the SQL migrations and applies them in version
+// order, recording each one in schema_migrations.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID is the Postgres advisory lock key held while migrating, so two
+// instances starting at once do not apply the same migration
+const lockID = 7346120951
+
+// Migration states reported by Status
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // Applied, but the file changed since
+	StateUnknown  = "unknown"  // Applied, but not in this binary
+)
+
+// Migration is one embedded migration. NNN_name.sql holds the up migration
+// and the optional NNN_name.down.sql reverts it; migrations without a down
+// file cannot be reverted, and neither can any migration before them.
+type Migration struct {
+	Version  int
+	Name     string
+	Checksum string // SHA-256 of the up migration
+	up       string
+	down     string
+}
+
+// Reversible reports whether the migration has a down migration
+func (m *Migration) Reversible() bool {
+	return m.down != ""
+}
+
+// MigrationStatus is a migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Checksum  string     `json:"checksum"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Load reads the embedded migrations in version order
+func Load() ([]*Migration, error) {
+	return load(files)
+}
+
+// load reads the migrations in fsys in version order. Down files must only
+// follow the last irreversible migration, since reverting stops there and an
+// earlier down file could never run.
+func load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, isDown := strings.CutSuffix(name, ".down.sql")
+		if !isDown {
+			base = strings.TrimSuffix(name, ".sql")
+		}
+
+		prefix, _, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: base}
+			byVersion[version] = m
+		}
+		if m.Name != base {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m.Name, base, version)
+		}
+
+		if isDown {
+			m.down = string(content)
+		} else {
+			sum := sha256.Sum256(content)
+			m.up = string(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %s has a down file but no up file", m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Reversible() {
+			continue
+		}
+		for _, m := range migrations[:i] {
+			if m.Reversible() {
+				return nil, fmt.Errorf("migration %s has a down file but cannot be reverted past %s, which has none", m.Name, migrations[i].Name)
+			}
+		}
+		break
+	}
+	return migrations, nil
+}
+
+// Runner applies and reverts migrations against a database
+type Runner struct {
+	db         *sql.DB
+	migrations []*Migration
+}
+
+// NewRunner creates a runner for the embedded migrations
+func NewRunner(db *sql.DB) (*Runner, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return &Runner{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies all pending migrations in order, each in its own transaction,
+// and returns the ones applied. It refuses to run if an applied migration was
+// modified. The early migrations are idempotent, so databases migrated by
+// hand with psql are simply recorded.
+func (r *Runner) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range r.migrations {
+			if a, ok := done[m.Version]; ok && a.checksum != m.Checksum {
+				return fmt.Errorf("migration %s was modified after it was applied (checksum %s, applied %s)", m.Name, m.Checksum[:12], a.checksum[:12])
+			}
+		}
+
+		for _, m := range r.migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			err := r.exec(ctx, conn, m.up,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				m.Version, m.Name, m.Checksum)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones reverted. It reverts nothing unless every one of them can be
+// reverted.
+func (r *Runner) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var reverted []*Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := r.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		targets, err := revertible(r.migrations, done, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range targets {
+			err := r.exec(ctx, conn, m.down,
+				`DELETE FROM schema_migrations WHERE version = $1`,
+				m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %s: %w", m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// revertible returns the last steps applied migrations, newest first, or an
+// error naming the first of them that has no down file
+func revertible(migrations []*Migration, done map[int]appliedMigration, steps int) ([]*Migration, error) {
+	var targets []*Migration
+	for i := len(migrations) - 1; i >= 0 && len(targets) < steps; i-- {
+		m := migrations[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		if !m.Reversible() {
+			return nil, fmt.Errorf("cannot revert %d migrations: %s has no down file, so at most %d can be reverted", steps, m.Name, len(targets))
+		}
+		targets = append(targets, m)
+	}
+	return targets, nil
+}
+
+// Status lists every embedded migration, and any applied migration this
+// binary does not know, in version order. It only reads schema_migrations,
+// without the migration lock, so it answers while another instance is
+// migrating and leaves a fresh database untouched.
+func (r *Runner) Status(ctx context.Context) ([]MigrationStatus, error) {
+	exists, err := r.tracked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]appliedMigration)
+	if exists {
+		done, err = r.applied(ctx, r.db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return compare(r.migrations, done), nil
+}
+
+// compare matches the embedded migrations against the applied ones
+func compare(migrations []*Migration, done map[int]appliedMigration) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	seen := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name, State: StatePending, Checksum: m.Checksum}
+		if a, ok := done[m.Version]; ok {
+			status.State = StateApplied
+			if a.checksum != m.Checksum {
+				status.State = StateModified
+			}
+			appliedAt := a.appliedAt
+			status.AppliedAt = &appliedAt
+		}
+		seen[m.Version] = true
+		statuses = append(statuses, status)
+	}
+
+	for version, a := range done {
+		if seen[version] {
+			continue
+		}
+		appliedAt := a.appliedAt
+		statuses = append(statuses, MigrationStatus{Version: version, Name: a.name, State: StateUnknown, Checksum: a.checksum, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
+
+// tracked reports whether schema_migrations exists, which it does once a
+// migration has been applied by the runner
+func (r *Runner) tracked(ctx context.Context) (bool, error) {
+	var table sql.NullString
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations')::text`).Scan(&table); err != nil {
+		return false, fmt.Errorf("failed to find schema_migrations: %w", err)
+	}
+	return table.Valid, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure schema_migrations exists
+func (r *Runner) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// querier is implemented by *sql.DB and *sql.Conn
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied returns the recorded migrations by version
+func (r *Runner) applied(ctx context.Context, conn querier) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = a
+	}
+	return done, rows.Err()
+}
+
+// exec runs a migration script and its schema_migrations bookkeeping in one
+// transaction
+func (r *Runner) exec(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,197 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int
+		wantErr      string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"002_b.sql":      file("B"),
+				"001_a.sql":      file("A"),
+				"010_c.sql":      file("C"),
+				"010_c.down.sql": file("-C"),
+			},
+			wantVersions: []int{1, 2, 10},
+		},
+		{
+			name:    "down file without up file",
+			files:   fstest.MapFS{"001_a.sql": file("A"), "002_b.down.sql": file("-B")},
+			wantErr: "has a down file but no up file",
+		},
+		{
+			name:    "no version number",
+			files:   fstest.MapFS{"init.sql": file("A")},
+			wantErr: "does not start with a version number",
+		},
+		{
+			name:    "shared version",
+			files:   fstest.MapFS{"001_a.sql": file("A"), "001_b.sql": file("B")},
+			wantErr: "share version 1",
+		},
+		{
+			name: "down file below a missing one",
+			files: fstest.MapFS{
+				"001_a.sql":      file("A"),
+				"001_a.down.sql": file("-A"),
+				"002_b.sql":      file("B"),
+				"003_c.sql":      file("C"),
+				"003_c.down.sql": file("-C"),
+			},
+			wantErr: "001_a has a down file but cannot be reverted past 002_b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			if len(versions) != len(tt.wantVersions) {
+				t.Fatalf("versions = %v, want %v", versions, tt.wantVersions)
+			}
+			for i := range versions {
+				if versions[i] != tt.wantVersions[i] {
+					t.Fatalf("versions = %v, want %v", versions, tt.wantVersions)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s has version %d, want %d (no gaps)", m.Name, m.Version, i+1)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %s has checksum %q", m.Name, m.Checksum)
+		}
+	}
+	// The audit trail migrations must never be reverted
+	if migrations[0].Reversible() {
+		t.Errorf("migration %s can be reverted", migrations[0].Name)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	migrations := []*Migration{
+		{Version: 1, Name: "001_a", Checksum: "aaa"},
+		{Version: 2, Name: "002_b", Checksum: "bbb"},
+		{Version: 3, Name: "003_c", Checksum: "ccc"},
+	}
+	done := map[int]appliedMigration{
+		1: {name: "001_a", checksum: "aaa", appliedAt: time.Now()},
+		2: {name: "002_b", checksum: "edited", appliedAt: time.Now()},
+		9: {name: "009_newer", checksum: "zzz", appliedAt: time.Now()},
+	}
+
+	statuses := compare(migrations, done)
+
+	want := []struct {
+		version int
+		state   string
+	}{
+		{1, StateApplied},
+		{2, StateModified},
+		{3, StatePending},
+		{9, StateUnknown},
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d", len(statuses), len(want))
+	}
+	for i, w := range want {
+		if statuses[i].Version != w.version || statuses[i].State != w.state {
+			t.Errorf("status %d = %d %s, want %d %s", i, statuses[i].Version, statuses[i].State, w.version, w.state)
+		}
+		if (w.state == StatePending) != (statuses[i].AppliedAt == nil) {
+			t.Errorf("status %d applied_at = %v", i, statuses[i].AppliedAt)
+		}
+	}
+	if len(done) != 3 {
+		t.Error("compare modified the applied migrations")
+	}
+}
+
+func TestRevertible(t *testing.T) {
+	migrations := []*Migration{
+		{Version: 1, Name: "001_a"},
+		{Version: 2, Name: "002_b", down: "-B"},
+		{Version: 3, Name: "003_c", down: "-C"},
+		{Version: 4, Name: "004_d", down: "-D"},
+	}
+	applied := func(versions ...int) map[int]appliedMigration {
+		done := make(map[int]appliedMigration)
+		for _, v := range versions {
+			done[v] = appliedMigration{}
+		}
+		return done
+	}
+
+	tests := []struct {
+		name    string
+		done    map[int]appliedMigration
+		steps   int
+		want    []int
+		wantErr string
+	}{
+		{name: "newest", done: applied(1, 2, 3, 4), steps: 1, want: []int{4}},
+		{name: "down to the first irreversible", done: applied(1, 2, 3, 4), steps: 3, want: []int{4, 3, 2}},
+		{name: "skips unapplied", done: applied(1, 2, 3), steps: 2, want: []int{3, 2}},
+		{name: "past an irreversible one", done: applied(1, 2, 3, 4), steps: 4, wantErr: "001_a has no down file, so at most 3 can be reverted"},
+		{name: "fewer applied than steps", done: applied(2, 3), steps: 5, want: []int{3, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := revertible(migrations, tt.done, tt.steps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if targets != nil {
+					t.Error("targets returned along with the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(targets) != len(tt.want) {
+				t.Fatalf("got %d targets, want %v", len(targets), tt.want)
+			}
+			for i, m := range targets {
+				if m.Version != tt.want[i] {
+					t.Errorf("target %d = %d, want %d", i, m.Version, tt.want[i])
+				}
+			}
+		})
+	}
+}